@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"gnarl/yarn"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// annotation tells why a resolution exists, kept in .gnarl/resolutions.json next to package.json.
+type annotation struct {
+	Advisories []string `json:"advisories,omitempty"`
+	Date       string   `json:"date"`
+	Reason     string   `json:"reason,omitempty"`
+	Expires    string   `json:"expires,omitempty"`
+}
+
+type annotations map[string]annotation
+
+func annotationsFile(directory string) string {
+	return filepath.Join(directory, ".gnarl", "resolutions.json")
+}
+
+func readAnnotations(directory string) (annotations, error) {
+	result := make(annotations)
+
+	data, err := ioutil.ReadFile(annotationsFile(directory))
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", annotationsFile(directory), err)
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("cannot deserialize %s: %v", annotationsFile(directory), err)
+	}
+
+	return result, nil
+}
+
+func (a annotations) save(directory string) error {
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot serialize %s: %v", annotationsFile(directory), err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(annotationsFile(directory)), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(annotationsFile(directory), append(data, '\n'), 0644)
+}
+
+// record adds the advisories and reason for a resolution, keeping the date it was first recorded.
+func (a annotations) record(key string, advisories []string, reason string, now time.Time, expiry time.Duration) {
+	existing, ok := a[key]
+	if !ok {
+		existing.Date = now.Format(dateLayout)
+		if expiry > 0 {
+			existing.Expires = now.Add(expiry).Format(dateLayout)
+		}
+	}
+
+	for _, advisory := range advisories {
+		if !contains(existing.Advisories, advisory) {
+			existing.Advisories = append(existing.Advisories, advisory)
+		}
+	}
+
+	existing.Reason = reason
+	a[key] = existing
+}
+
+func (entry annotation) expired(now time.Time) bool {
+	expires, err := time.Parse(dateLayout, entry.Expires)
+	return err == nil && !now.Before(expires.AddDate(0, 0, 1))
+}
+
+// parseExpiry accepts durations like 720h and a number of days like 90d.
+func parseExpiry(expiry string) (time.Duration, error) {
+	if expiry == "" {
+		return 0, nil
+	}
+
+	if strings.HasSuffix(expiry, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(expiry, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid number of days %q", expiry)
+		}
+
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(expiry)
+}
+
+// recordSuggestions remembers the advisories behind the suggested resolutions of the lock.
+func recordSuggestions(lock *yarn.Lock, reason string) {
+	suggestions := lock.Suggestions()
+	if dryRun || len(suggestions) == 0 {
+		return
+	}
+
+	existing, err := readAnnotations(cwd)
+	if err != nil {
+		errorLog.Fatal(err)
+	}
+
+	expiry, _ := parseExpiry(configuration.Audit.ResolutionExpiry)
+	for key := range suggestions {
+		existing.record(key, lock.Advisories(key), reason, time.Now(), expiry)
+	}
+
+	if err := existing.save(cwd); err != nil {
+		errorLog.Fatal(err)
+	}
+
+	log.Printf("recorded %d resolutions in %s", len(suggestions), annotationsFile(cwd))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"gnarl/yarn"
+	"testing"
+	"time"
+)
+
+func TestRecordAnnotations(t *testing.T) {
+	inTempProject(t, map[string]string{})
+
+	first := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	known := make(annotations)
+	known.record("lodash@^4.17.0", []string{"GHSA-1"}, "suggested by gnarl audit", first, 30*24*time.Hour)
+	known.record("lodash@^4.17.0", []string{"GHSA-2", "GHSA-1"}, "added by gnarl audit", first.AddDate(0, 0, 5), 0)
+
+	if err := known.save("."); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := readAnnotations(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := read["lodash@^4.17.0"]
+	if entry.Date != "2026-01-10" || entry.Expires != "2026-02-09" || entry.Reason != "added by gnarl audit" || len(entry.Advisories) != 2 {
+		t.Errorf("Unexpected annotation %+v", entry)
+	}
+
+	if entry.expired(time.Date(2026, 2, 9, 23, 0, 0, 0, time.UTC)) || !entry.expired(time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Expected the annotation to expire after 2026-02-09")
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	for expiry, expected := range map[string]time.Duration{"": 0, "90d": 90 * 24 * time.Hour, "36h": 36 * time.Hour} {
+		if actual, err := parseExpiry(expiry); err != nil || actual != expected {
+			t.Errorf("Expected %q to parse as %v, got %v (%v)", expiry, expected, actual, err)
+		}
+	}
+
+	if _, err := parseExpiry("soon"); err == nil {
+		t.Error("Expected an invalid expiry error")
+	}
+}
+
+func TestFixedUpstream(t *testing.T) {
+	analysis := yarn.ResolutionAnalysis{
+		Key:     "lodash",
+		Ranges:  []string{"^4.17.0"},
+		Natural: map[string]string{"^4.17.0": "4.17.21"},
+	}
+	entry := annotation{Advisories: []string{"GHSA-1"}}
+
+	if !fixedUpstream(analysis, entry) {
+		t.Error("Expected the advisory to be fixed upstream")
+	}
+
+	analysis.NaturalAdvisories = []string{"GHSA-1"}
+	if fixedUpstream(analysis, entry) {
+		t.Error("Expected the advisory to still affect the natural resolution")
+	}
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"gnarl/semver"
+	"gnarl/yarn"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type auditReport struct {
+	Advisories  []yarn.Advisory    `json:"advisories"`
+	Suggestions map[string]string  `json:"suggestions"`
+	Resolutions []resolutionReport `json:"resolutions"`
+}
+
+type auditOptions struct {
+	database         string
+	crossCheck       string
+	yarn             bool
+	timeout          time.Duration
+	severity         string
+	ignore           string
+	applySuggestions bool
+	maxIterations    int
+	steps            string
+}
+
+func auditFlags(flags *flag.FlagSet, auto bool) *auditOptions {
+	options := auditOptions{}
+
+	flags.StringVar(&options.database, "offline", "", "audit against a local advisory database instead of the npm registry")
+	flags.StringVar(&options.crossCheck, "cross-check", "", "compare npm patched versions with a local advisory database")
+	flags.BoolVar(&options.yarn, "yarn", false, "audit with yarn npm audit instead of the npm bulk advisory endpoint")
+	flags.DurationVar(&options.timeout, "timeout", 0, "abort yarn commands running longer than this duration")
+	flags.StringVar(&options.severity, "severity", "info", "ignore advisories below this severity: "+strings.Join(severities, ", "))
+	flags.StringVar(&options.ignore, "ignore", "", "comma separated advisory ids and package patterns to ignore")
+	flags.BoolVar(&options.applySuggestions, "apply-suggestions", false, "add the suggested resolutions to package.json")
+	if auto {
+		flags.IntVar(&options.maxIterations, "max-iterations", 10, "give up when yarn.lock is not stable after this many iterations (0 for no limit)")
+		flags.StringVar(&options.steps, "steps", strings.Join(autoSteps, ","), "comma separated steps of an iteration: "+strings.Join(autoSteps, ", "))
+	}
+
+	return &options
+}
+
+func (options auditOptions) validate() error {
+	if severityRank(options.severity) < 0 {
+		return fmt.Errorf("--severity must be one of %s, got %q", strings.Join(severities, ", "), options.severity)
+	}
+
+	for _, step := range splitList(options.steps) {
+		if !contains(autoSteps, step) {
+			return fmt.Errorf("--steps must be a subset of %s, got %q", strings.Join(autoSteps, ", "), step)
+		}
+	}
+
+	return nil
+}
+
+func audit(ctx context.Context, runner yarn.Runner, project *yarn.Package, options auditOptions) bool {
+	var advisories []yarn.Advisory
+	switch {
+	case options.database != "":
+		advisories = auditOffline(options.database)
+	case options.yarn:
+		advisories = auditYarn(ctx, runner)
+	default:
+		advisories = auditRegistry()
+	}
+
+	if options.crossCheck != "" {
+		crossCheck(advisories, options.crossCheck)
+	}
+
+	advisories = options.filter(advisories, ignoredByYarn(options.database != ""))
+
+	lock := mustReadLock()
+	fixAdvisories(lock, advisories)
+
+	var registry *yarn.Registry
+	if options.database == "" {
+		registry = mustReadRegistry()
+	}
+
+	resolutions := check(project, lock, advisories, registry, false)
+	enforcePolicy(lock)
+
+	report(auditReport{Advisories: advisories, Suggestions: lock.Suggestions(), Resolutions: resolutions})
+
+	applied := options.applySuggestions && applySuggestions(lock)
+	if applied {
+		recordSuggestions(lock, "added by gnarl audit")
+	} else {
+		recordSuggestions(lock, "suggested by gnarl audit")
+	}
+
+	return mustSaveLock(lock) || applied
+}
+
+// ignoredByYarn lists npmAuditIgnoreAdvisories and npmAuditExcludePackages of .yarnrc.yml. An offline audit goes on
+// without them when .yarnrc.yml cannot be read.
+func ignoredByYarn(offline bool) []string {
+	config, err := yarn.ReadConfig(cwd)
+	if err != nil {
+		if !offline {
+			errorLog.Fatal(err)
+		}
+
+		log.Printf("cannot read the audit ignore lists: %v", err)
+		return nil
+	}
+
+	return append(config.NpmAuditIgnoreAdvisories, config.NpmAuditExcludePackages...)
+}
+
+// filter drops advisories below the severity threshold and the ignored ones,
+// including those ignored by npmAuditIgnoreAdvisories and npmAuditExcludePackages of .yarnrc.yml.
+func (options auditOptions) filter(advisories []yarn.Advisory, ignoredByYarn []string) []yarn.Advisory {
+	threshold := severityRank(options.severity)
+	ignore := append(splitList(options.ignore), ignoredByYarn...)
+
+	var result []yarn.Advisory
+	for _, advisory := range advisories {
+		if rank := severityRank(advisory.Severity); rank >= 0 && rank < threshold {
+			log.Printf("ignoring %s advisory %s for %s", advisory.Severity, advisory.Identifier(), advisory.ModuleName)
+			continue
+		}
+
+		if ignored(advisory, ignore) {
+			log.Printf("ignoring advisory %s for %s", advisory.Identifier(), advisory.ModuleName)
+			continue
+		}
+
+		result = append(result, advisory)
+	}
+
+	return result
+}
+
+func severityRank(severity string) int {
+	for rank, candidate := range severities {
+		if candidate == severity {
+			return rank
+		}
+	}
+
+	return -1
+}
+
+func ignored(advisory yarn.Advisory, ignore []string) bool {
+	for _, item := range ignore {
+		for _, candidate := range []string{advisory.Identifier(), strconv.Itoa(advisory.Id), advisory.GithubAdvisoryId, advisory.ModuleName} {
+			if matched, _ := path.Match(item, candidate); matched && candidate != "" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// enforcePolicy reports the locked packages denied by the configuration, and fails when there are any.
+func enforcePolicy(lock *yarn.Lock) {
+	var violations int
+	for _, deny := range configuration.Policy.Deny {
+		pattern, err := yarn.ParseResetPattern(deny)
+		if err != nil {
+			errorLog.Fatal(err)
+		}
+
+		for _, locked := range lock.Matching(pattern) {
+			violations++
+			errorLog.Printf("policy violation: %s@%s is denied by %s", locked.Name, locked.Version, deny)
+		}
+	}
+
+	if violations > 0 {
+		errorLog.Fatalf("%d policy violations", violations)
+	}
+}
+
+func applySuggestions(lock *yarn.Lock) bool {
+	suggestions := lock.Suggestions()
+	if len(suggestions) == 0 {
+		return false
+	}
+
+	field := lock.Manager().OverrideField()
+	if dryRun {
+		log.Printf("dry run: not adding %d %s to package.json", len(suggestions), field)
+		return false
+	}
+
+	if err := yarn.UpdateOverrides(cwd, field, suggestions, nil); err != nil {
+		errorLog.Fatal(err)
+	}
+
+	log.Printf("added %d %s to package.json, needs `yarn install`", len(suggestions), field)
+	return true
+}
+
+func auditRegistry() []yarn.Advisory {
+	registry := mustReadRegistry()
+	log.Printf("npm bulk advisories from %s", registry.Server)
+
+	advisories, err := registry.BulkAdvisories(mustReadLock())
+	if err != nil {
+		errorLog.Fatal(err)
+	}
+
+	return advisories
+}
+
+func auditYarn(ctx context.Context, runner yarn.Runner) []yarn.Advisory {
+	if lockfileName() != yarn.BerryLockfile.Name() {
+		errorLog.Fatalf("--yarn needs a yarn.lock, leave it out to audit %s against the npm registry", lockfileName())
+	}
+
+	version, err := yarn.DetectVersion(cwd, mustReadConfig())
+	if err != nil {
+		log.Printf("%v, asking yarn --version", err)
+		if version, err = runner.Version(ctx); err != nil {
+			errorLog.Fatal(err)
+		}
+	}
+
+	if version.Major < 2 {
+		errorLog.Fatalf("yarn %s has no `yarn npm audit`, leave out --yarn to audit against the npm registry", version)
+	}
+
+	log.Print("yarn npm audit --recursive")
+	out, err := runner.Audit(ctx)
+	if err != nil && (version.Major < 4 || ctx.Err() != nil) {
+		errorLog.Fatal(err)
+	}
+
+	advisories, err := yarn.ParseAudit(out, version)
+	if err != nil {
+		errorLog.Fatal(err)
+	}
+
+	return advisories
+}
+
+func auditOffline(database string) []yarn.Advisory {
+	db, err := yarn.ReadAdvisoryDatabase(database)
+	if err != nil {
+		errorLog.Fatal(err)
+	}
+
+	log.Printf("offline audit against %d advisories", db.Len())
+
+	return db.Audit(mustReadLock())
+}
+
+func deprecated(ctx context.Context, runner yarn.Runner, useYarn bool) bool {
+	var deprecations []yarn.Deprecation
+	if useYarn {
+		log.Print("yarn npm audit --recursive")
+		out, err := runner.Audit(ctx)
+		if ctx.Err() != nil {
+			errorLog.Fatal(err)
+		}
+
+		deprecations, err = yarn.ParseDeprecationsYarn4(out)
+		if err != nil {
+			errorLog.Fatal(err)
+		}
+	} else {
+		deprecations = yarn.FindDeprecations(mustReadLock(), mustReadRegistry())
+	}
+
+	if report(deprecations) {
+		return len(deprecations) == 0
+	}
+
+	for _, deprecation := range deprecations {
+		log.Printf("deprecated %s@%s: %s", deprecation.ModuleName, deprecation.Version, deprecation.Message)
+		for _, dependent := range deprecation.Dependents {
+			fmt.Fprintf(yarn.Output, "    required by %s\n", dependent)
+		}
+	}
+
+	if len(deprecations) == 0 {
+		log.Print("no deprecated packages")
+	}
+
+	return len(deprecations) == 0
+}
+
+func crossCheck(advisories []yarn.Advisory, database string) {
+	db, err := yarn.ReadAdvisoryDatabase(database)
+	if err != nil {
+		errorLog.Fatal(err)
+	}
+
+	findings := db.CrossCheck(advisories)
+	for _, finding := range findings {
+		log.Printf("cross-check: %s", finding)
+	}
+
+	if len(findings) == 0 {
+		log.Printf("cross-check: npm patched versions agree with %s", database)
+	}
+}
+
+func fixAdvisories(lock *yarn.Lock, advisories []yarn.Advisory) {
+	for _, advisory := range advisories {
+		request, err := semver.ParseRequest(advisory.PatchedVersions)
+		if err != nil {
+			log.Printf("skipping advisory %s for %s: invalid safe-version-request: %v", advisory.Identifier(), advisory.ModuleName, err)
+			continue
+		}
+
+		lock.Fix(advisory.ModuleName, request, advisory.Identifier())
+	}
+
+	if len(advisories) == 0 {
+		log.Print("all packages considered safe")
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"gnarl/yarn"
+	"log"
+	"time"
+)
+
+func auto(ctx context.Context, runner yarn.Runner, project *yarn.Package, options auditOptions) error {
+	if dryRun {
+		log.Print("dry run: skipping yarn install and yarn dedupe")
+		audit(ctx, runner, project, options)
+		return nil
+	}
+
+	steps := splitList(options.steps)
+	if len(steps) == 0 {
+		steps = autoSteps
+	}
+	start := time.Now()
+	seen := make(map[string]int)
+
+	for iteration := 1; ; iteration++ {
+		if options.maxIterations > 0 && iteration > options.maxIterations {
+			return fmt.Errorf("yarn.lock still changing after %d iterations in %v", options.maxIterations, time.Since(start).Round(time.Second))
+		}
+
+		before, _ := yarn.ReadLock(cwd)
+
+		if contains(steps, "install") {
+			log.Print("yarn install")
+			if err := runner.Install(ctx); err != nil {
+				return err
+			}
+		}
+
+		if contains(steps, "dedupe") {
+			log.Print("yarn dedupe")
+			if err := runner.Dedupe(ctx); err != nil {
+				return err
+			}
+		}
+
+		hash, err := mustReadLock().Hash()
+		if err != nil {
+			return err
+		}
+
+		if previous, ok := seen[hash]; ok {
+			return fmt.Errorf("yarn.lock oscillates: iteration %d resolves to the same state as iteration %d", iteration, previous)
+		}
+
+		seen[hash] = iteration
+
+		dirty := contains(steps, "audit") && audit(ctx, runner, project, options)
+		log.Printf("iteration %d: %s", iteration, yarn.DiffLocks(before, mustReadLock()).Summary())
+
+		if !dirty {
+			log.Printf("yarn.lock stable after %d iterations in %v", iteration, time.Since(start).Round(time.Second))
+			return nil
+		}
+	}
+}
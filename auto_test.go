@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"gnarl/semver"
+	"gnarl/yarn"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+const vulnerableLock = `"app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "app@workspace:."
+  dependencies:
+    lodash: ^4.17.0
+  languageName: unknown
+  linkType: soft
+
+"lodash@npm:^4.17.0":
+  version: 4.17.20
+  resolution: "lodash@npm:4.17.20"
+  languageName: node
+  linkType: hard
+`
+
+type fakeRunner struct {
+	installs, dedupes int
+	onInstall         func(installs int)
+}
+
+func (runner *fakeRunner) Install(ctx context.Context) error {
+	runner.installs++
+	runner.onInstall(runner.installs)
+	return nil
+}
+
+func (runner *fakeRunner) Dedupe(ctx context.Context) error {
+	runner.dedupes++
+	return nil
+}
+
+func (runner *fakeRunner) Version(ctx context.Context) (*semver.Version, error) {
+	return semver.ParseVersion("4.0.0")
+}
+
+func (runner *fakeRunner) Audit(ctx context.Context) ([]byte, error) {
+	return nil, nil
+}
+
+func inTempProject(t *testing.T, files map[string]string) {
+	directory := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(directory+"/"+name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(directory); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.Chdir(previous) })
+}
+
+func TestAutoResolvesVulnerablePackage(t *testing.T) {
+	inTempProject(t, map[string]string{
+		"package.json":    `{"name": "app"}`,
+		"yarn.lock":       vulnerableLock,
+		"advisories.json": `[{"id": 1, "module_name": "lodash", "vulnerable_versions": "<4.17.21"}]`,
+	})
+
+	runner := &fakeRunner{onInstall: func(installs int) {
+		lock, err := ioutil.ReadFile("yarn.lock")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if installs > 1 && !strings.Contains(string(lock), "lodash@npm:^4.17.0") {
+			fixed := strings.ReplaceAll(vulnerableLock, "4.17.20", "4.17.21")
+			if err := ioutil.WriteFile("yarn.lock", []byte(fixed), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}}
+
+	err := auto(context.Background(), runner, &yarn.Package{}, auditOptions{database: "advisories.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if runner.installs != 2 || runner.dedupes != 2 {
+		t.Errorf("Expected two iterations, got %d installs and %d dedupes", runner.installs, runner.dedupes)
+	}
+
+	lock, err := ioutil.ReadFile("yarn.lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(lock), "lodash@npm:4.17.21") {
+		t.Errorf("Expected lodash 4.17.21 to be locked, got\n%s", lock)
+	}
+}
+
+func TestAutoStopsOnOscillation(t *testing.T) {
+	inTempProject(t, map[string]string{
+		"package.json":    `{"name": "app"}`,
+		"yarn.lock":       vulnerableLock,
+		"advisories.json": `[{"id": 1, "module_name": "lodash", "vulnerable_versions": "<4.17.21"}]`,
+	})
+
+	runner := &fakeRunner{onInstall: func(int) {
+		if err := ioutil.WriteFile("yarn.lock", []byte(vulnerableLock), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}}
+
+	err := auto(context.Background(), runner, &yarn.Package{}, auditOptions{database: "advisories.json", maxIterations: 5})
+	if err == nil || !strings.Contains(err.Error(), "oscillates") {
+		t.Errorf("Expected oscillation to be detected, got %v", err)
+	}
+
+	if runner.installs != 2 {
+		t.Errorf("Expected to stop in the second iteration, got %d installs", runner.installs)
+	}
+}
+
+func TestOfflineAuditWithBrokenYarnrc(t *testing.T) {
+	inTempProject(t, map[string]string{
+		"package.json":    `{"name": "app"}`,
+		"yarn.lock":       vulnerableLock,
+		".yarnrc.yml":     "npmAuditIgnoreAdvisories: [\n",
+		"advisories.json": `[{"id": 1, "module_name": "lodash", "vulnerable_versions": "<4.17.21"}]`,
+	})
+
+	if !audit(context.Background(), &fakeRunner{}, &yarn.Package{}, auditOptions{database: "advisories.json"}) {
+		t.Error("Expected the vulnerable package to be reset")
+	}
+
+	if lock := mustReadFile(t, "yarn.lock"); strings.Contains(lock, "4.17.20") {
+		t.Errorf("Expected lodash 4.17.20 to be reset, got\n%s", lock)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"gnarl/yarn"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// cacheCommand prunes the archives yarn.lock no longer refers to from the project cache, or lists the lock entries
+// without an archive.
+func cacheCommand(args []string) error {
+	if len(args) != 1 || args[0] != "prune" && args[0] != "missing" {
+		return fmt.Errorf("expected prune or missing")
+	}
+
+	lock := mustReadLock()
+	if manager := lock.Format().Manager(); manager != yarn.Berry {
+		return fmt.Errorf("cache %s needs a yarn 2 or later yarn.lock, got a %s lockfile", args[0], manager)
+	}
+
+	cache := mustReadCache()
+	if args[0] == "missing" {
+		return cacheMissing(cache, lock)
+	}
+
+	return cachePrune(cache, lock)
+}
+
+func cacheMissing(cache *yarn.Cache, lock *yarn.Lock) error {
+	missing := cache.Missing(lock)
+	if !report(missing) {
+		for _, locator := range missing {
+			log.Printf("no archive for %s", locator)
+		}
+
+		if len(missing) == 0 {
+			log.Printf("all lock entries have an archive in %s", cache.Folder)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%d lock entries have no archive in %s", len(missing), cache.Folder)
+	}
+
+	return nil
+}
+
+func cachePrune(cache *yarn.Cache, lock *yarn.Lock) error {
+	if cache.Global {
+		return fmt.Errorf("refusing to prune the global cache %s, other projects use it too", cache.Folder)
+	}
+
+	unreferenced, err := cache.Unreferenced(lock)
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	for _, archive := range unreferenced {
+		if info, err := os.Stat(archive); err == nil {
+			size += info.Size()
+		}
+
+		if dryRun {
+			log.Printf("dry run: not removing %s", filepath.Base(archive))
+			continue
+		}
+
+		if err := os.Remove(archive); err != nil {
+			return err
+		}
+
+		log.Printf("removed %s", filepath.Base(archive))
+	}
+
+	report(unreferenced)
+	if len(unreferenced) == 0 {
+		log.Printf("%s holds no unreferenced archives", cache.Folder)
+	} else if !dryRun {
+		log.Printf("removed %d archives, %.1f MB", len(unreferenced), float64(size)/1e6)
+	}
+
+	return nil
+}
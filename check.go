@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"gnarl/yarn"
+	"log"
+	"strings"
+	"time"
+)
+
+type resolutionReport struct {
+	yarn.ResolutionAnalysis
+	Annotation    *annotation `json:"annotation,omitempty"`
+	Expired       bool        `json:"expired,omitempty"`
+	FixedUpstream bool        `json:"fixedUpstream,omitempty"`
+}
+
+// check reports resolutions and overrides that are unused, force a vulnerable or outdated version, are no longer needed,
+// have expired or were added for advisories fixed upstream, and removes the unneeded ones from package.json when pruning.
+func check(project *yarn.Package, lock *yarn.Lock, advisories []yarn.Advisory, registry *yarn.Registry, prune bool) []resolutionReport {
+	var metadata yarn.Metadata
+	if registry != nil {
+		metadata = registry
+	}
+
+	overrides, err := project.Overrides()
+	if err != nil {
+		errorLog.Fatal(err)
+	}
+
+	analyses := lock.AnalyzeResolutions(overrides, metadata)
+	var unassessed error
+	if versions := yarn.NaturalVersions(analyses); registry != nil && len(versions) > 0 {
+		natural, err := registry.BulkAdvisoriesFor(versions)
+		unassessed = err
+		advisories = append(append([]yarn.Advisory{}, advisories...), natural...)
+	}
+
+	yarn.AssessResolutions(analyses, advisories)
+	if unassessed != nil {
+		// Without the advisories of the natural versions every one of them would look safe.
+		for i := range analyses {
+			if len(analyses[i].Natural) > 0 && analyses[i].Error == "" {
+				analyses[i].Error = fmt.Sprintf("cannot audit natural versions: %v", unassessed)
+				analyses[i].Prunable = false
+			}
+		}
+	}
+
+	known, err := readAnnotations(cwd)
+	if err != nil {
+		errorLog.Fatal(err)
+	}
+
+	good := true
+	now := time.Now()
+	var reports []resolutionReport
+	prunable := make(map[string][]string)
+	for _, analysis := range analyses {
+		result := resolutionReport{ResolutionAnalysis: analysis}
+		if entry, ok := known[analysis.Key]; ok {
+			result.Annotation = &entry
+			result.Expired = entry.expired(now)
+			result.FixedUpstream = fixedUpstream(analysis, entry)
+		}
+
+		reports = append(reports, result)
+
+		switch {
+		case analysis.Error != "":
+			good = false
+			log.Printf("cannot check resolution %s: %s", analysis.Key, analysis.Error)
+		case len(analysis.Ranges) == 0:
+			good = false
+			log.Printf("superfluous resolution %s: no dependency matches", analysis.Key)
+		}
+
+		if len(analysis.Advisories) > 0 {
+			good = false
+			log.Printf("resolution %s forces %s, vulnerable to %s", analysis.Key, analysis.Version, strings.Join(analysis.Advisories, ", "))
+		}
+
+		if analysis.Newer != "" {
+			good = false
+			log.Printf("resolution %s forces %s, %s is available", analysis.Key, analysis.Version, analysis.Newer)
+		}
+
+		if result.Expired {
+			good = false
+			log.Printf("resolution %s expired on %s: %s", analysis.Key, result.Annotation.Expires, result.Annotation.Reason)
+		}
+
+		if result.FixedUpstream {
+			good = false
+			log.Printf("resolution %s was for %s, fixed upstream", analysis.Key, strings.Join(result.Annotation.Advisories, ", "))
+		}
+
+		if !analysis.Prunable {
+			continue
+		}
+
+		prunable[analysis.Source] = append(prunable[analysis.Source], analysis.Key)
+		if len(analysis.Ranges) > 0 {
+			good = false
+			for _, rng := range analysis.Ranges {
+				log.Printf("resolution %s no longer needed: %s would resolve to %s", analysis.Key, rng, analysis.Natural[rng])
+			}
+		}
+	}
+
+	if good {
+		log.Print("all resolutions good")
+	}
+
+	if prune && len(prunable) > 0 {
+		pruneOverrides(prunable, known)
+	}
+
+	return reports
+}
+
+// fixedUpstream tells whether all ranges would resolve to versions unaffected by the advisories of the annotation.
+func fixedUpstream(analysis yarn.ResolutionAnalysis, entry annotation) bool {
+	if len(entry.Advisories) == 0 || len(analysis.Ranges) == 0 || len(analysis.Natural) < len(analysis.Ranges) {
+		return false
+	}
+
+	for _, natural := range analysis.Natural {
+		if natural == "" {
+			return false
+		}
+	}
+
+	for _, advisory := range entry.Advisories {
+		if contains(analysis.NaturalAdvisories, advisory) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func pruneOverrides(keysBySource map[string][]string, known annotations) {
+	pruned := false
+	for source, keys := range keysBySource {
+		if dryRun {
+			log.Printf("dry run: not removing %s from %s", strings.Join(keys, ", "), source)
+			continue
+		}
+
+		if err := yarn.UpdateOverrides(cwd, source, nil, keys); err != nil {
+			errorLog.Fatal(err)
+		}
+
+		log.Printf("removed %s from %s, needs `yarn install`", strings.Join(keys, ", "), source)
+		for _, key := range keys {
+			if _, ok := known[key]; ok {
+				delete(known, key)
+				pruned = true
+			}
+		}
+	}
+
+	if pruned {
+		if err := known.save(cwd); err != nil {
+			errorLog.Fatal(err)
+		}
+	}
+}
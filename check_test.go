@@ -0,0 +1,38 @@
+package main
+
+import (
+	"gnarl/yarn"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckKeepsResolutionsWhenAuditFails(t *testing.T) {
+	resetGlobals(t)
+	inTempProject(t, map[string]string{
+		"package.json": `{"name": "app", "resolutions": {"lodash": "4.17.20"}}`,
+		"yarn.lock":    "__metadata:\n  version: 6\n  cacheKey: 8\n\n" + vulnerableLock,
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte(`{"name": "lodash", "versions": {"4.17.20": {"version": "4.17.20"}, "4.17.21": {"version": "4.17.21"}}}`))
+	}))
+	defer server.Close()
+
+	registry := yarn.NewRegistry(&yarn.Config{NpmRegistryServer: server.URL})
+	reports := check(mustReadPackage(), mustReadLock(), nil, registry, true)
+
+	if len(reports) != 1 || reports[0].Prunable || reports[0].Error == "" {
+		t.Errorf("Expected an unassessed resolution, got %+v", reports)
+	}
+
+	if content := mustReadFile(t, "package.json"); !strings.Contains(content, `"lodash": "4.17.20"`) {
+		t.Errorf("Expected the resolution to be kept, got %s", content)
+	}
+}
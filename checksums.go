@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"gnarl/yarn"
+	"log"
+)
+
+// mustReadCache locates the yarn cache of the project.
+func mustReadCache() *yarn.Cache {
+	config := mustReadConfig()
+	version, err := yarn.DetectVersion(cwd, config)
+	if err != nil {
+		version = nil
+	}
+
+	return yarn.NewCache(config, version)
+}
+
+// verifyChecksums compares the archives in the yarn cache with the checksums in yarn.lock, without network access.
+func verifyChecksums(lock *yarn.Lock) error {
+	if manager := lock.Format().Manager(); manager != yarn.Berry {
+		return fmt.Errorf("verify-checksums needs a yarn 2 or later yarn.lock, %s lockfiles have no archive checksums", manager)
+	}
+
+	cache := mustReadCache()
+	log.Printf("verifying checksums against %s", cache.Folder)
+
+	problems, verified, err := cache.VerifyChecksums(lock)
+	if err != nil {
+		return err
+	}
+
+	if !report(problems) {
+		for _, problem := range problems {
+			log.Print(problem)
+		}
+
+		if len(problems) == 0 {
+			log.Printf("all %d archives match their checksums", verified)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d checksum problems, %d archives match", len(problems), verified)
+	}
+
+	return nil
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gnarl/yarn"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// command is a verb. Mutating verbs accept --dry-run, project verbs read package.json and standalone verbs do not
+// read the configuration, so that a broken .gnarlrc.yml does not keep them from running.
+type command struct {
+	name       string
+	args       string
+	summary    string
+	mutating   bool
+	project    bool
+	standalone bool
+	setup      func(flags *flag.FlagSet) func(env *environment, args []string) error
+}
+
+type environment struct {
+	ctx     context.Context
+	runner  *yarn.ExecRunner
+	project *yarn.Package
+}
+
+var (
+	cwd        = "."
+	verbose    bool
+	quiet      bool
+	jsonOutput bool
+	dryRun     bool
+	yarnPath   string
+)
+
+var configuration = &settings{}
+
+var errorLog = log.New(os.Stderr, "", log.LstdFlags)
+
+func globalFlags(flags *flag.FlagSet) {
+	flags.StringVar(&cwd, "cwd", cwd, "run in this project directory")
+	flags.BoolVar(&verbose, "verbose", verbose, "stream the output of yarn")
+	flags.BoolVar(&quiet, "quiet", quiet, "only report errors and results")
+	flags.BoolVar(&jsonOutput, "json", jsonOutput, "print results as json")
+	flags.StringVar(&yarnPath, "yarn-path", yarnPath, "run this yarn binary or release instead of yarn from the PATH")
+}
+
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func (c *command) flagSet() (*flag.FlagSet, func(env *environment, args []string) error) {
+	flags := flag.NewFlagSet(c.name, flag.ExitOnError)
+	flags.Usage = func() { c.help(flags) }
+	globalFlags(flags)
+	if c.mutating {
+		flags.BoolVar(&dryRun, "dry-run", false, "print the lockfile changes instead of saving them")
+	}
+
+	run := func(*environment, []string) error { return nil }
+	if c.setup != nil {
+		run = c.setup(flags)
+	}
+
+	return flags, run
+}
+
+// invocation is a parsed command line.
+type invocation struct {
+	command *command
+	globals *flag.FlagSet
+	flags   *flag.FlagSet
+	run     func(env *environment, args []string) error
+	args    []string
+}
+
+// parseCommandLine finds the verb, auto by default, and parses the global flags and those of the verb.
+func parseCommandLine(arguments []string) (*invocation, error) {
+	globals := flag.NewFlagSet("gnarl", flag.ExitOnError)
+	globals.Usage = func() { help(nil) }
+	globalFlags(globals)
+	globals.Parse(arguments)
+
+	verb, args := "auto", globals.Args()
+	if len(args) > 0 {
+		verb, args = args[0], args[1:]
+	}
+
+	c := findCommand(verb)
+	if c == nil {
+		return nil, fmt.Errorf("unknown verb: %s, see `gnarl help`", verb)
+	}
+
+	flags, run := c.flagSet()
+	return &invocation{command: c, globals: globals, flags: flags, run: run, args: parseFlags(flags, args)}, nil
+}
+
+// parseFlags parses the flags before, between and after the positional arguments, which it returns. Arguments
+// following -- are positional only.
+func parseFlags(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for len(args) > 0 {
+		flags.Parse(args)
+		rest := flags.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...)
+		}
+
+		if len(rest) == 0 {
+			break
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+
+	return positional
+}
+
+func (c *command) usage() string {
+	usage := fmt.Sprintf("gnarl %s [flags]", c.name)
+	if c.args != "" {
+		usage += " " + c.args
+	}
+
+	return usage
+}
+
+func (c *command) help(flags *flag.FlagSet) {
+	w := flags.Output()
+	fmt.Fprintf(w, "usage: %s\n\n%s\n\nflags:\n", c.usage(), c.summary)
+	flags.PrintDefaults()
+}
+
+func help(args []string) error {
+	if len(args) > 0 {
+		c := findCommand(args[0])
+		if c == nil {
+			return fmt.Errorf("unknown verb: %s", args[0])
+		}
+
+		flags, _ := c.flagSet()
+		flags.SetOutput(os.Stdout)
+		c.help(flags)
+		return nil
+	}
+
+	fmt.Printf("gnarl %s - the yarn v2/v3/v4 companion tool\n\n", version)
+	fmt.Print("usage: gnarl [global flags] [<verb>] [flags] [args]\n\nverbs:\n")
+	for _, c := range commands {
+		fmt.Printf("  %-12s %s\n", c.name, c.summary)
+	}
+
+	fmt.Print("\nglobal flags:\n")
+	flags := flag.NewFlagSet("gnarl", flag.ContinueOnError)
+	flags.SetOutput(os.Stdout)
+	globalFlags(flags)
+	flags.PrintDefaults()
+
+	fmt.Print("\nrun `gnarl help <verb>` for the flags of a verb\n")
+	return nil
+}
+
+func configureOutput(runner *yarn.ExecRunner) {
+	if quiet {
+		log.SetOutput(ioutil.Discard)
+	}
+
+	if verbose {
+		runner.Stdout = os.Stderr
+	}
+
+	if jsonOutput {
+		yarn.Output = os.Stderr
+	}
+}
+
+// report prints a result as json when requested, and tells whether it did.
+func report(result interface{}) bool {
+	if !jsonOutput {
+		return false
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		errorLog.Fatal(err)
+	}
+
+	return true
+}
+
+func commandFlags(c *command) []*flag.Flag {
+	flags, _ := c.flagSet()
+
+	var result []*flag.Flag
+	flags.VisitAll(func(f *flag.Flag) { result = append(result, f) })
+	sort.Slice(result, func(p, q int) bool { return result[p].Name < result[q].Name })
+
+	return result
+}
+
+func completion(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected one of bash, zsh or fish")
+	}
+
+	switch args[0] {
+	case "bash":
+		fmt.Print(bashCompletion())
+	case "zsh":
+		fmt.Print(zshCompletion())
+	case "fish":
+		fmt.Print(fishCompletion())
+	default:
+		return fmt.Errorf("unsupported shell: %s", args[0])
+	}
+
+	return nil
+}
+
+func bashCompletion() string {
+	var b strings.Builder
+	var verbs []string
+	for _, c := range commands {
+		verbs = append(verbs, c.name)
+	}
+
+	b.WriteString("_gnarl() {\n")
+	b.WriteString("    local cur=${COMP_WORDS[COMP_CWORD]} verb= word opts\n")
+	b.WriteString("    for word in \"${COMP_WORDS[@]:1:COMP_CWORD-1}\"; do\n")
+	b.WriteString("        case $word in -*) ;; *) verb=$word; break ;; esac\n")
+	b.WriteString("    done\n")
+	b.WriteString("    case $verb in\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "        %s) opts=\"%s\" ;;\n", c.name, strings.Join(flagNames(commandFlags(c)), " "))
+	}
+	fmt.Fprintf(&b, "        *) opts=\"%s --cwd --verbose --quiet --json --yarn-path\" ;;\n", strings.Join(verbs, " "))
+	b.WriteString("    esac\n")
+	b.WriteString("    COMPREPLY=($(compgen -W \"$opts\" -- \"$cur\"))\n")
+	b.WriteString("}\n")
+	b.WriteString("complete -o default -F _gnarl gnarl\n")
+
+	return b.String()
+}
+
+func zshCompletion() string {
+	var b strings.Builder
+
+	b.WriteString("#compdef gnarl\n\n")
+	b.WriteString("_gnarl() {\n")
+	b.WriteString("    local -a verbs\n")
+	b.WriteString("    verbs=(\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "        '%s:%s'\n", c.name, zshEscape(c.summary))
+	}
+	b.WriteString("    )\n")
+	b.WriteString("    if (( CURRENT == 2 )); then\n")
+	b.WriteString("        _describe 'verb' verbs\n")
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n")
+	b.WriteString("    case $words[2] in\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "        %s)\n            _arguments", c.name)
+		for _, f := range commandFlags(c) {
+			fmt.Fprintf(&b, " \\\n                '--%s[%s]'", f.Name, zshEscape(f.Usage))
+		}
+		b.WriteString(" \\\n                '*:file:_files'\n            ;;\n")
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("}\n\n")
+	b.WriteString("_gnarl \"$@\"\n")
+
+	return b.String()
+}
+
+func fishCompletion() string {
+	var b strings.Builder
+
+	for _, c := range commands {
+		fmt.Fprintf(&b, "complete -c gnarl -n __fish_use_subcommand -a %s -d %s\n", c.name, fishQuote(c.summary))
+	}
+
+	for _, c := range commands {
+		for _, f := range commandFlags(c) {
+			fmt.Fprintf(&b, "complete -c gnarl -n '__fish_seen_subcommand_from %s' -l %s -d %s\n", c.name, f.Name, fishQuote(f.Usage))
+		}
+	}
+
+	return b.String()
+}
+
+func flagNames(flags []*flag.Flag) []string {
+	var names []string
+	for _, f := range flags {
+		names = append(names, "--"+f.Name)
+	}
+
+	return names
+}
+
+func zshEscape(text string) string {
+	return strings.NewReplacer("'", "'\\''", "[", "\\[", "]", "\\]", ":", "\\:").Replace(text)
+}
+
+func fishQuote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", "\\'") + "'"
+}
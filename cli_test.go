@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// resetGlobals restores the global flags and the configuration a test may have set through execute.
+func resetGlobals(t *testing.T) {
+	directory, verboseOutput, quietOutput, json, dry, path := cwd, verbose, quiet, jsonOutput, dryRun, yarnPath
+	t.Cleanup(func() {
+		cwd, verbose, quiet, jsonOutput, dryRun, yarnPath = directory, verboseOutput, quietOutput, json, dry, path
+		configuration = &settings{}
+	})
+}
+
+func mustReadFile(t *testing.T, name string) string {
+	content, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(content)
+}
+
+func TestFlagsAfterArguments(t *testing.T) {
+	resetGlobals(t)
+	inTempProject(t, map[string]string{
+		"package.json": `{"name": "app"}`,
+		"yarn.lock":    vulnerableLock,
+	})
+
+	if err := execute(context.Background(), []string{"reset", "lodash", "--dry-run"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if lock := mustReadFile(t, "yarn.lock"); lock != vulnerableLock {
+		t.Errorf("Expected --dry-run after the pattern to leave yarn.lock unchanged, got\n%s", lock)
+	}
+}
+
+func TestWhyRejectsNonPositiveLimit(t *testing.T) {
+	resetGlobals(t)
+	inTempProject(t, map[string]string{
+		"package.json": `{"name": "app"}`,
+		"yarn.lock":    vulnerableLock,
+	})
+
+	if err := execute(context.Background(), []string{"why", "lodash", "--limit", "0"}); err == nil || !strings.Contains(err.Error(), "--limit") {
+		t.Errorf("Expected --limit 0 to be rejected, got %v", err)
+	}
+}
+
+func TestParseCommandLine(t *testing.T) {
+	for _, c := range []struct {
+		arguments []string
+		verb      string
+		args      []string
+		flags     map[string]string
+		json      bool
+		dryRun    bool
+		cwd       string
+	}{
+		{arguments: nil, verb: "auto"},
+		{arguments: []string{"--json", "why", "lodash", "--limit", "3"}, verb: "why", args: []string{"lodash"}, flags: map[string]string{"limit": "3"}, json: true},
+		{arguments: []string{"reset", "--cwd", "sub", "left", "--transitive", "right"}, verb: "reset", args: []string{"left", "right"}, flags: map[string]string{"transitive": "true"}, cwd: "sub"},
+		{arguments: []string{"fix", "lodash", ">=4.17.21", "--dry-run"}, verb: "fix", args: []string{"lodash", ">=4.17.21"}, dryRun: true},
+		{arguments: []string{"--quiet", "audit", "--severity", "high", "--json", "--ignore", "GHSA-1,left"}, verb: "audit", flags: map[string]string{"severity": "high", "ignore": "GHSA-1,left", "quiet": "true"}, json: true},
+		{arguments: []string{"diff", "--", "--json", "-"}, verb: "diff", args: []string{"--json", "-"}},
+	} {
+		resetGlobals(t)
+		cwd, jsonOutput, dryRun = ".", false, false
+
+		invoked, err := parseCommandLine(c.arguments)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if invoked.command.name != c.verb || !reflect.DeepEqual(invoked.args, c.args) {
+			t.Errorf("%v: expected %s %v, got %s %v", c.arguments, c.verb, c.args, invoked.command.name, invoked.args)
+		}
+
+		for name, expected := range c.flags {
+			if actual := invoked.flags.Lookup(name).Value.String(); actual != expected {
+				t.Errorf("%v: expected --%s %s, got %s", c.arguments, name, expected, actual)
+			}
+		}
+
+		if expectedCwd := c.cwd; jsonOutput != c.json || dryRun != c.dryRun || expectedCwd != "" && cwd != expectedCwd {
+			t.Errorf("%v: unexpected globals json %v, dry run %v, cwd %s", c.arguments, jsonOutput, dryRun, cwd)
+		}
+	}
+
+	if _, err := parseCommandLine([]string{"unknown"}); err == nil {
+		t.Error("Expected an unknown verb error")
+	}
+}
+
+func TestCompletionGolden(t *testing.T) {
+	for shell, generate := range map[string]func() string{"bash": bashCompletion, "zsh": zshCompletion, "fish": fishCompletion} {
+		golden := filepath.Join("testdata", "completion."+shell)
+		if *update {
+			if err := ioutil.WriteFile(golden, []byte(generate()), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if expected := mustReadFile(t, golden); generate() != expected {
+			t.Errorf("%s completion differs from %s, run go test -update if the verbs or flags changed on purpose", shell, golden)
+		}
+	}
+}
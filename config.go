@@ -0,0 +1,394 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gnarl/yarn"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	yaml2 "gopkg.in/yaml.v2"
+)
+
+const gnarlrc = ".gnarlrc.yml"
+
+var severities = []string{"info", "low", "moderate", "high", "critical"}
+
+var autoSteps = []string{"install", "dedupe", "audit"}
+
+type settings struct {
+	Audit    auditSettings  `yaml:"audit"`
+	Auto     autoSettings   `yaml:"auto"`
+	Policy   policySettings `yaml:"policy"`
+	Output   string         `yaml:"output"`
+	YarnPath string         `yaml:"yarnPath"`
+}
+
+type auditSettings struct {
+	Severity         string   `yaml:"severity"`
+	Ignore           []string `yaml:"ignore"`
+	ApplySuggestions *bool    `yaml:"applySuggestions"`
+	ResolutionExpiry string   `yaml:"resolutionExpiry"`
+}
+
+type autoSettings struct {
+	MaxIterations *int     `yaml:"maxIterations"`
+	Steps         []string `yaml:"steps"`
+}
+
+type policySettings struct {
+	Deny     []string        `yaml:"deny"`
+	Licenses licenseSettings `yaml:"licenses"`
+}
+
+type licenseSettings struct {
+	Allow      []string `yaml:"allow"`
+	Deny       []string `yaml:"deny"`
+	Exceptions []string `yaml:"exceptions"`
+}
+
+// readSettings layers the .gnarlrc.yml of the project root, the one of the workspace and the GNARL_* environment.
+func readSettings(directory string) (*settings, error) {
+	result := settings{}
+
+	var paths []string
+	if root := projectRoot(directory); root != "" {
+		paths = append(paths, filepath.Join(root, gnarlrc))
+	}
+
+	if path := filepath.Join(directory, gnarlrc); len(paths) == 0 || !sameFile(paths[0], path) {
+		paths = append(paths, path)
+	}
+
+	for _, path := range paths {
+		layer, err := readSettingsFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if layer != nil {
+			result.merge(layer)
+		}
+	}
+
+	layer, err := environmentSettings(os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	result.merge(layer)
+	return &result, nil
+}
+
+// projectRoot returns the closest directory holding a lockfile, workspaces share the one of their project.
+func projectRoot(directory string) string {
+	current, err := filepath.Abs(directory)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if _, err := yarn.DetectLockfile(current); err == nil {
+			return current
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return ""
+		}
+
+		current = parent
+	}
+}
+
+func sameFile(p string, q string) bool {
+	p, _ = filepath.Abs(p)
+	q, _ = filepath.Abs(q)
+	return p == q
+}
+
+func readSettingsFile(path string) (*settings, error) {
+	yaml, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", path, err)
+	}
+
+	layer := settings{}
+	if err := yaml2.UnmarshalStrict(yaml, &layer); err != nil {
+		return nil, fmt.Errorf("cannot deserialize %s: %v", path, err)
+	}
+
+	if err := layer.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return &layer, nil
+}
+
+func environmentSettings(lookup func(string) (string, bool)) (*settings, error) {
+	layer := settings{}
+
+	if value, ok := lookup("GNARL_AUDIT_SEVERITY"); ok {
+		layer.Audit.Severity = value
+	}
+
+	if value, ok := lookup("GNARL_AUDIT_IGNORE"); ok {
+		layer.Audit.Ignore = splitList(value)
+	}
+
+	if value, ok := lookup("GNARL_AUDIT_APPLY_SUGGESTIONS"); ok {
+		apply, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("GNARL_AUDIT_APPLY_SUGGESTIONS must be true or false, got %q", value)
+		}
+
+		layer.Audit.ApplySuggestions = &apply
+	}
+
+	if value, ok := lookup("GNARL_AUDIT_RESOLUTION_EXPIRY"); ok {
+		layer.Audit.ResolutionExpiry = value
+	}
+
+	if value, ok := lookup("GNARL_AUTO_MAX_ITERATIONS"); ok {
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("GNARL_AUTO_MAX_ITERATIONS must be a number, got %q", value)
+		}
+
+		layer.Auto.MaxIterations = &count
+	}
+
+	if value, ok := lookup("GNARL_AUTO_STEPS"); ok {
+		layer.Auto.Steps = splitList(value)
+	}
+
+	if value, ok := lookup("GNARL_POLICY_DENY"); ok {
+		layer.Policy.Deny = splitList(value)
+	}
+
+	if value, ok := lookup("GNARL_POLICY_LICENSES_ALLOW"); ok {
+		layer.Policy.Licenses.Allow = splitList(value)
+	}
+
+	if value, ok := lookup("GNARL_POLICY_LICENSES_DENY"); ok {
+		layer.Policy.Licenses.Deny = splitList(value)
+	}
+
+	if value, ok := lookup("GNARL_POLICY_LICENSES_EXCEPTIONS"); ok {
+		layer.Policy.Licenses.Exceptions = splitList(value)
+	}
+
+	if value, ok := lookup("GNARL_OUTPUT"); ok {
+		layer.Output = value
+	}
+
+	if value, ok := lookup("GNARL_YARN_PATH"); ok {
+		layer.YarnPath = value
+	}
+
+	if err := layer.validate(); err != nil {
+		return nil, fmt.Errorf("GNARL_* environment: %v", err)
+	}
+
+	return &layer, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func (s *settings) validate() error {
+	if s.Audit.Severity != "" && !contains(severities, s.Audit.Severity) {
+		return fmt.Errorf("audit.severity must be one of %s, got %q", strings.Join(severities, ", "), s.Audit.Severity)
+	}
+
+	if _, err := parseExpiry(s.Audit.ResolutionExpiry); err != nil {
+		return fmt.Errorf("audit.resolutionExpiry must be a duration like 720h or 90d: %v", err)
+	}
+
+	if s.Auto.MaxIterations != nil && *s.Auto.MaxIterations < 0 {
+		return fmt.Errorf("auto.maxIterations must be 0 (no limit) or more, got %d", *s.Auto.MaxIterations)
+	}
+
+	for _, step := range s.Auto.Steps {
+		if !contains(autoSteps, step) {
+			return fmt.Errorf("auto.steps must be a subset of %s, got %q", strings.Join(autoSteps, ", "), step)
+		}
+	}
+
+	for _, pattern := range s.Policy.Deny {
+		if _, err := yarn.ParseResetPattern(pattern); err != nil {
+			return fmt.Errorf("policy.deny: %v", err)
+		}
+	}
+
+	for _, pattern := range s.Policy.Licenses.Exceptions {
+		if _, err := yarn.ParseResetPattern(pattern); err != nil {
+			return fmt.Errorf("policy.licenses.exceptions: %v", err)
+		}
+	}
+
+	if s.Output != "" && s.Output != "text" && s.Output != "json" {
+		return fmt.Errorf("output must be text or json, got %q", s.Output)
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *settings) merge(layer *settings) {
+	if layer.Audit.Severity != "" {
+		s.Audit.Severity = layer.Audit.Severity
+	}
+
+	if layer.Audit.Ignore != nil {
+		s.Audit.Ignore = layer.Audit.Ignore
+	}
+
+	if layer.Audit.ApplySuggestions != nil {
+		s.Audit.ApplySuggestions = layer.Audit.ApplySuggestions
+	}
+
+	if layer.Audit.ResolutionExpiry != "" {
+		s.Audit.ResolutionExpiry = layer.Audit.ResolutionExpiry
+	}
+
+	if layer.Auto.MaxIterations != nil {
+		s.Auto.MaxIterations = layer.Auto.MaxIterations
+	}
+
+	if layer.Auto.Steps != nil {
+		s.Auto.Steps = layer.Auto.Steps
+	}
+
+	if layer.Policy.Deny != nil {
+		s.Policy.Deny = layer.Policy.Deny
+	}
+
+	if layer.Policy.Licenses.Allow != nil {
+		s.Policy.Licenses.Allow = layer.Policy.Licenses.Allow
+	}
+
+	if layer.Policy.Licenses.Deny != nil {
+		s.Policy.Licenses.Deny = layer.Policy.Licenses.Deny
+	}
+
+	if layer.Policy.Licenses.Exceptions != nil {
+		s.Policy.Licenses.Exceptions = layer.Policy.Licenses.Exceptions
+	}
+
+	if layer.Output != "" {
+		s.Output = layer.Output
+	}
+
+	if layer.YarnPath != "" {
+		s.YarnPath = layer.YarnPath
+	}
+}
+
+// flagValues maps the settings to the flags overriding them.
+func (s *settings) flagValues() map[string]string {
+	values := make(map[string]string)
+
+	if s.Audit.Severity != "" {
+		values["severity"] = s.Audit.Severity
+	}
+
+	if s.Audit.Ignore != nil {
+		values["ignore"] = strings.Join(s.Audit.Ignore, ",")
+	}
+
+	if s.Audit.ApplySuggestions != nil {
+		values["apply-suggestions"] = strconv.FormatBool(*s.Audit.ApplySuggestions)
+	}
+
+	if s.Auto.MaxIterations != nil {
+		values["max-iterations"] = strconv.Itoa(*s.Auto.MaxIterations)
+	}
+
+	if s.Auto.Steps != nil {
+		values["steps"] = strings.Join(s.Auto.Steps, ",")
+	}
+
+	if s.Output != "" {
+		values["json"] = strconv.FormatBool(s.Output == "json")
+	}
+
+	if s.YarnPath != "" {
+		values["yarn-path"] = s.YarnPath
+	}
+
+	return values
+}
+
+// apply sets the flags that were not given on the command line to the configured values.
+func (s *settings) apply(flags *flag.FlagSet, explicit map[string]bool) error {
+	for name, value := range s.flagValues() {
+		if explicit[name] || flags.Lookup(name) == nil {
+			continue
+		}
+
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("invalid configuration for --%s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+func explicitFlags(flagSets ...*flag.FlagSet) map[string]bool {
+	explicit := make(map[string]bool)
+	for _, flags := range flagSets {
+		flags.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	}
+
+	return explicit
+}
+
+// configureYarn runs yarnPath of .gnarlrc.yml or else of .yarnrc.yml instead of yarn from the PATH,
+// JavaScript releases are run by node.
+func configureYarn(runner *yarn.ExecRunner) {
+	path := yarnPath
+	switch {
+	case path != "" && !filepath.IsAbs(path):
+		path = filepath.Join(cwd, path)
+	case path == "":
+		config, err := yarn.ReadConfig(cwd)
+		if err != nil || config.YarnPath == "" {
+			return
+		}
+
+		path = config.Path(config.YarnPath)
+	}
+
+	path, _ = filepath.Abs(path)
+	switch filepath.Ext(path) {
+	case ".cjs", ".js", ".mjs":
+		runner.Binary, runner.Args = "node", []string{path}
+	default:
+		runner.Binary = path
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSettingsLayering(t *testing.T) {
+	inTempProject(t, map[string]string{
+		"yarn.lock":    vulnerableLock,
+		".gnarlrc.yml": "audit:\n  severity: high\n  ignore: [GHSA-1]\nauto:\n  maxIterations: 3\noutput: json\n",
+	})
+
+	if err := os.Mkdir("workspace", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile("workspace/.gnarlrc.yml", []byte("audit:\n  severity: critical\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := readSettings("workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Audit.Severity != "critical" || len(config.Audit.Ignore) != 1 || *config.Auto.MaxIterations != 3 || config.Output != "json" {
+		t.Errorf("Expected the workspace to override the project settings, got %+v", config)
+	}
+}
+
+func TestSettingsValidation(t *testing.T) {
+	inTempProject(t, map[string]string{
+		".gnarlrc.yml": "audit:\n  severity: extreme\n",
+	})
+
+	_, err := readSettings(".")
+	if err == nil || !strings.Contains(err.Error(), "audit.severity must be one of") {
+		t.Errorf("Expected an invalid severity error, got %v", err)
+	}
+
+	inTempProject(t, map[string]string{
+		".gnarlrc.yml": "audit:\n  severty: high\n",
+	})
+
+	if _, err := readSettings("."); err == nil || !strings.Contains(err.Error(), "severty") {
+		t.Errorf("Expected an unknown field error, got %v", err)
+	}
+}
+
+func TestEnvironmentSettings(t *testing.T) {
+	environment := map[string]string{"GNARL_AUDIT_IGNORE": "lodash, 1234", "GNARL_AUTO_MAX_ITERATIONS": "0", "GNARL_POLICY_LICENSES_ALLOW": "MIT,ISC"}
+	lookup := func(name string) (string, bool) {
+		value, ok := environment[name]
+		return value, ok
+	}
+
+	layer, err := environmentSettings(lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(layer.Audit.Ignore, ",") != "lodash,1234" || *layer.Auto.MaxIterations != 0 || strings.Join(layer.Policy.Licenses.Allow, ",") != "MIT,ISC" {
+		t.Errorf("Unexpected settings %+v", layer)
+	}
+
+	environment["GNARL_AUTO_MAX_ITERATIONS"] = "many"
+	if _, err := environmentSettings(lookup); err == nil {
+		t.Error("Expected an invalid number error")
+	}
+}
+
+func TestFlagsOverrideSettings(t *testing.T) {
+	flags := flag.NewFlagSet("auto", flag.ContinueOnError)
+	options := auditFlags(flags, true)
+	if err := flags.Parse([]string{"--severity", "low"}); err != nil {
+		t.Fatal(err)
+	}
+
+	iterations := 2
+	config := &settings{Audit: auditSettings{Severity: "high"}, Auto: autoSettings{MaxIterations: &iterations}}
+	if err := config.apply(flags, explicitFlags(flags)); err != nil {
+		t.Fatal(err)
+	}
+
+	if options.severity != "low" || options.maxIterations != 2 {
+		t.Errorf("Expected --severity to win and maxIterations to be configured, got %+v", *options)
+	}
+}
+
+func TestInvalidAuditOptionsFail(t *testing.T) {
+	resetGlobals(t)
+	for _, c := range []struct {
+		name        string
+		args        []string
+		environment string
+		gnarlrc     string
+	}{
+		{name: "severity flag", args: []string{"audit", "--offline", "advisories.json", "--severity", "bogus"}},
+		{name: "steps flag", args: []string{"auto", "--steps", "install,bogus"}},
+		{name: "severity environment", args: []string{"audit", "--offline", "advisories.json"}, environment: "GNARL_AUDIT_SEVERITY"},
+		{name: "steps environment", args: []string{"auto"}, environment: "GNARL_AUTO_STEPS"},
+		{name: "severity gnarlrc", args: []string{"audit", "--offline", "advisories.json"}, gnarlrc: "audit:\n  severity: bogus\n"},
+		{name: "steps gnarlrc", args: []string{"auto"}, gnarlrc: "auto:\n  steps: [bogus]\n"},
+	} {
+		files := map[string]string{
+			"package.json":    `{"name": "app"}`,
+			"yarn.lock":       vulnerableLock,
+			"advisories.json": `[{"id": 1, "module_name": "lodash", "vulnerable_versions": "<4.17.21"}]`,
+		}
+
+		if c.gnarlrc != "" {
+			files[".gnarlrc.yml"] = c.gnarlrc
+		}
+
+		inTempProject(t, files)
+		if c.environment != "" {
+			os.Setenv(c.environment, "bogus")
+		}
+
+		err := execute(context.Background(), c.args)
+		os.Unsetenv(c.environment)
+		if err == nil || !strings.Contains(err.Error(), "bogus") {
+			t.Errorf("%s: expected an error about bogus, got %v", c.name, err)
+		}
+
+		if lock := mustReadFile(t, "yarn.lock"); lock != vulnerableLock {
+			t.Errorf("%s: expected yarn.lock to be unchanged, got\n%s", c.name, lock)
+		}
+	}
+}
+
+func TestStandaloneVerbsIgnoreBrokenSettings(t *testing.T) {
+	resetGlobals(t)
+	inTempProject(t, map[string]string{
+		"package.json": `{"name": "app"}`,
+		"yarn.lock":    vulnerableLock,
+		".gnarlrc.yml": "audit: [broken\n",
+	})
+
+	for _, args := range [][]string{{"help"}, {"help", "audit"}, {"completion", "bash"}} {
+		if err := execute(context.Background(), args); err != nil {
+			t.Errorf("%s: expected no error, got %v", strings.Join(args, " "), err)
+		}
+	}
+
+	if err := execute(context.Background(), []string{"reset", "lodash"}); err == nil || !strings.Contains(err.Error(), gnarlrc) {
+		t.Errorf("Expected the broken %s to be reported, got %v", gnarlrc, err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gnarl/yarn"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// lockfileFormats are the names --from and --to accept.
+var lockfileFormats = map[string]yarn.Lockfile{
+	"berry":        yarn.BerryLockfile,
+	"yarn-classic": yarn.ClassicLockfile,
+	"npm":          yarn.NpmLockfile,
+	"pnpm":         yarn.PnpmLockfile,
+}
+
+func parseLockfileFormat(flag string, name string) (yarn.Lockfile, error) {
+	if format, ok := lockfileFormats[name]; ok {
+		return format, nil
+	}
+
+	var names []string
+	for candidate := range lockfileFormats {
+		names = append(names, candidate)
+	}
+
+	sort.Strings(names)
+	return nil, fmt.Errorf("--%s must be one of %s, got %q", flag, strings.Join(names, ", "), name)
+}
+
+// metadataFlags adds the flags of the registry metadata used without network access with --offline.
+func metadataFlags(flags *flag.FlagSet) func() *yarn.CachedMetadata {
+	offline := flags.Bool("offline", false, "only use cached registry metadata")
+	cache := flags.String("cache", "", "registry metadata cache directory (default .gnarl/registry)")
+	return func() *yarn.CachedMetadata {
+		metadata := &yarn.CachedMetadata{Directory: *cache}
+		if metadata.Directory == "" {
+			metadata.Directory = filepath.Join(cwd, ".gnarl", "registry")
+		}
+
+		if !*offline {
+			metadata.Upstream = mustReadRegistry()
+		}
+
+		return metadata
+	}
+}
+
+// convert writes the lockfile of the project in the format of another package manager, keeping the locked versions.
+func convert(from string, to string, metadata yarn.Metadata) error {
+	if to == "" {
+		return fmt.Errorf("--to is required")
+	}
+
+	target, err := parseLockfileFormat("to", to)
+	if err != nil {
+		return err
+	}
+
+	read := yarn.ReadLock
+	if from != "" {
+		source, err := parseLockfileFormat("from", from)
+		if err != nil {
+			return err
+		}
+
+		read = func(directory string) (*yarn.Lock, error) { return yarn.ReadLockFormat(directory, source) }
+	}
+
+	lock, err := read(cwd)
+	if err != nil {
+		return err
+	}
+
+	source := lock.Format()
+	if source.Manager() == target.Manager() {
+		return fmt.Errorf("%s already is a %s lockfile", source.Name(), to)
+	}
+
+	converted := lock.Convert(target, metadata)
+	if dryRun {
+		data, err := target.Serialize(converted)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("dry run: not writing %s", target.Name())
+		_, err = yarn.Output.Write(data)
+		return err
+	}
+
+	if err := converted.WriteFile(filepath.Join(cwd, target.Name())); err != nil {
+		return err
+	}
+
+	log.Printf("converted %s to %s, check it with `%s install`", source.Name(), target.Name(), target.Manager().Command())
+	if source.Name() != target.Name() {
+		log.Printf("remove %s and update packageManager in package.json once the migration is done", source.Name())
+	}
+
+	return nil
+}
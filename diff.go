@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"gnarl/yarn"
+	"os"
+	"os/exec"
+)
+
+func diff(args []string) error {
+	var old, new *yarn.Lock
+	var err error
+
+	switch len(args) {
+	case 1:
+		if old, err = readLockRevision(args[0]); err != nil {
+			return err
+		}
+
+		if new, err = yarn.ReadLock(cwd); err != nil {
+			return err
+		}
+	case 2:
+		if old, err = readLockRevision(args[0]); err != nil {
+			return err
+		}
+
+		if new, err = readLockRevision(args[1]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("expected <old-yarn.lock> <new-yarn.lock> or <git-ref>")
+	}
+
+	if lockDiff := yarn.DiffLocks(old, new); !report(lockDiff) {
+		lockDiff.Print()
+	}
+
+	return nil
+}
+
+func readLockRevision(source string) (*yarn.Lock, error) {
+	if info, err := os.Stat(source); err == nil && !info.IsDir() {
+		return yarn.ReadLockFile(source)
+	}
+
+	name := lockfileName()
+	git := exec.Command("git", "show", source+":./"+name)
+	git.Dir = cwd
+	out, err := git.Output()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s at %s: %v", name, source, err)
+	}
+
+	return yarn.ParseLock(out)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"gnarl/yarn"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// snapshot reads all files of the current directory, .gnarl and the yarn cache included.
+func snapshot(t *testing.T) map[string]string {
+	files := make(map[string]string)
+	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		content, err := ioutil.ReadFile(path)
+		files[path] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return files
+}
+
+func inDryRunProject(t *testing.T) {
+	fixedLock := strings.ReplaceAll(vulnerableLock, "4.17.20", "4.17.21")
+	inTempProject(t, map[string]string{
+		"package.json":    `{"name": "app", "resolutions": {"left-pad": "1.3.0"}}`,
+		"yarn.lock":       "__metadata:\n  version: 6\n  cacheKey: 8\n\n" + vulnerableLock,
+		".yarnrc.yml":     "enableGlobalCache: false\n",
+		"advisories.json": `[{"id": 1, "module_name": "lodash", "vulnerable_versions": "<4.17.21", "title": "Prototype pollution"}]`,
+		"base.lock":       vulnerableLock,
+		"ours.lock":       vulnerableLock,
+		"theirs.lock":     fixedLock,
+	})
+
+	if err := os.MkdirAll(filepath.Join(".yarn", "cache"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(".yarn", "cache", "left-pad-npm-1.3.0-0123456789-8.zip"), []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDryRunLeavesProjectUntouched(t *testing.T) {
+	for _, args := range [][]string{
+		{"audit", "--dry-run", "--offline", "advisories.json", "--apply-suggestions"},
+		{"fix", "--dry-run", "lodash", ">=4.17.21"},
+		{"reset", "--dry-run", "lodash"},
+		{"check", "--dry-run", "--offline", "advisories.json", "--prune"},
+		{"convert", "--dry-run", "--offline", "--to", "npm"},
+		{"merge", "--dry-run", "base.lock", "ours.lock", "theirs.lock"},
+		{"cache", "--dry-run", "prune"},
+	} {
+		resetGlobals(t)
+		inDryRunProject(t)
+		before := snapshot(t)
+
+		if err := execute(context.Background(), args); err != nil {
+			t.Errorf("%s: %v", strings.Join(args, " "), err)
+		}
+
+		if after := snapshot(t); !reflect.DeepEqual(before, after) {
+			t.Errorf("%s: expected no changes, got\n%v", strings.Join(args, " "), after)
+		}
+	}
+}
+
+func TestAutoDryRunSkipsYarn(t *testing.T) {
+	resetGlobals(t)
+	inDryRunProject(t)
+	before := snapshot(t)
+
+	dryRun = true
+	runner := &fakeRunner{onInstall: func(int) { t.Error("Expected no yarn install") }}
+	if err := auto(context.Background(), runner, &yarn.Package{}, auditOptions{database: "advisories.json", applySuggestions: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if runner.installs != 0 || runner.dedupes != 0 {
+		t.Errorf("Expected no yarn commands, got %d installs and %d dedupes", runner.installs, runner.dedupes)
+	}
+
+	if after := snapshot(t); !reflect.DeepEqual(before, after) {
+		t.Errorf("Expected no changes, got\n%v", after)
+	}
+}
@@ -1,207 +1,393 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"gnarl/semver"
 	"gnarl/yarn"
-	"log"
 	"os"
-	"os/exec"
+	"os/signal"
 	"strings"
 )
 
 func mustReadPackage() *yarn.Package {
-	lock, err := yarn.ReadPackage(".")
+	lock, err := yarn.ReadPackage(cwd)
 	if err != nil {
-		log.Fatal(err)
+		errorLog.Fatal(err)
 	}
 
 	return lock
 }
 
 func mustReadLock() *yarn.Lock {
-	lock, err := yarn.ReadLock(".")
+	lock, err := yarn.ReadLock(cwd)
 	if err != nil {
-		log.Fatal(err)
+		errorLog.Fatal(err)
 	}
 
+	lock.SetManager(yarn.DetectManager(cwd))
 	return lock
 }
 
-func mustSaveLock(lock *yarn.Lock) bool {
-	dirty, err := lock.Save(".")
-	if err != nil {
-		log.Fatal(err)
+// lockfileName is the name of the lockfile of the project, yarn.lock when there is none yet.
+func lockfileName() string {
+	if format, err := yarn.DetectLockfile(cwd); err == nil {
+		return format.Name()
 	}
 
-	return dirty
+	return yarn.BerryLockfile.Name()
 }
 
-const version string = "1.0.0-rc-2"
+func mustReadConfig() *yarn.Config {
+	config, err := yarn.ReadConfig(cwd)
+	if err != nil {
+		errorLog.Fatal(err)
+	}
 
-func help() {
-	log.Printf("gnarl %s - the yarn v2/v3 companion tool", version)
-	log.Print("usage: gnarl [<auto | audit | check | fix | help | reset> <args>]")
-	log.Print("> gnarl [auto]")
-	log.Print("> gnarl audit")
-	log.Print("> gnarl check")
-	log.Print("> gnarl fix package-name safe-version-request")
-	log.Print("> gnarl help")
-	log.Print("> gnarl shrink")
-	log.Print("> gnarl reset package-names...")
+	return config
 }
 
-func main() {
-	var verb string
-	if len(os.Args) > 1 {
-		switch os.Args[1] {
-		case "audit":
-		case "check":
-		case "fix":
-		case "help":
-		case "reset":
-		case "shrink":
-		default:
-			log.Fatalf("unknown verb: %s", os.Args[1])
-		}
-		verb = os.Args[1]
-	} else {
-		verb = "auto"
-	}
+func mustReadRegistry() *yarn.Registry {
+	return yarn.NewRegistry(mustReadConfig())
+}
 
-	var project *yarn.Package
-	if verb != "help" {
-		project = mustReadPackage()
+func mustSaveLock(lock *yarn.Lock) bool {
+	save := lock.Save
+	if dryRun {
+		save = lock.Preview
 	}
 
-	switch verb {
-	case "auto":
-		for {
-			log.Print("yarn install")
-			_, err := exec.Command("yarn", "install").Output()
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			log.Print("yarn dedupe")
-			_, err = exec.Command("yarn", "dedupe").Output()
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			if !audit(project) {
-				break
-			}
-		}
-
-	case "audit":
-		audit(project)
-
-	case "check":
-		lock := mustReadLock()
-		check(project, lock)
-
-	case "fix":
-		if len(os.Args) < 4 {
-			help()
-			log.Fatal("insufficient arguments")
-		}
-
-		npmPackage := os.Args[2]
-		request, err := semver.ParseRequest(strings.Join(os.Args[3:], " "))
-		if err != nil {
-			log.Fatalf("invalid safe-version-request: %v", err)
-		}
-
-		lock := mustReadLock()
-		lock.Fix(npmPackage, request)
-		mustSaveLock(lock)
-
-	case "help":
-		help()
-
-	case "reset":
-		lock := mustReadLock()
-
-		for _, arg := range os.Args[1:] {
-			lock.Reset(arg)
-		}
+	dirty, err := save(cwd)
+	if err != nil {
+		errorLog.Fatal(err)
+	}
 
-		mustSaveLock(lock)
+	return dirty
+}
 
-	case "shrink":
-		lock := mustReadLock()
-		lock.Shrink()
-		mustSaveLock(lock)
+const version string = "1.0.0-rc-2"
 
-	default:
-		log.Fatalf("unreachable verb %s", verb)
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			name:     "auto",
+			summary:  "repeat yarn install, yarn dedupe and gnarl audit until yarn.lock is stable (default)",
+			mutating: true,
+			project:  true,
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				options := auditFlags(flags, true)
+				return func(env *environment, args []string) error {
+					if err := options.validate(); err != nil {
+						return err
+					}
+
+					env.runner.Timeout = options.timeout
+					return auto(env.ctx, env.runner, env.project, *options)
+				}
+			},
+		},
+		{
+			name:     "audit",
+			summary:  "reset packages with a safe fix, suggest resolutions for the others and check resolutions",
+			mutating: true,
+			project:  true,
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				options := auditFlags(flags, false)
+				return func(env *environment, args []string) error {
+					if err := options.validate(); err != nil {
+						return err
+					}
+
+					env.runner.Timeout = options.timeout
+					audit(env.ctx, env.runner, env.project, *options)
+					return nil
+				}
+			},
+		},
+		{
+			name:     "cache",
+			args:     "<prune | missing>",
+			summary:  "remove archives yarn.lock no longer refers to from the yarn cache, or list entries without one",
+			mutating: true,
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				return func(env *environment, args []string) error {
+					return cacheCommand(args)
+				}
+			},
+		},
+		{
+			name:     "check",
+			summary:  "check whether resolutions are still in use, safe, up to date and needed",
+			mutating: true,
+			project:  true,
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				database := flags.String("offline", "", "check against a local advisory database instead of the npm registry")
+				prune := flags.Bool("prune", false, "remove the resolutions that are no longer needed from package.json")
+				return func(env *environment, args []string) error {
+					var advisories []yarn.Advisory
+					var registry *yarn.Registry
+					if *database != "" {
+						advisories = auditOffline(*database)
+					} else {
+						advisories, registry = auditRegistry(), mustReadRegistry()
+					}
+
+					report(check(env.project, mustReadLock(), advisories, registry, *prune))
+					return nil
+				}
+			},
+		},
+		{
+			name:       "completion",
+			args:       "<bash | zsh | fish>",
+			summary:    "print a shell completion script",
+			standalone: true,
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				return func(env *environment, args []string) error {
+					return completion(args)
+				}
+			},
+		},
+		{
+			name:     "convert",
+			summary:  "write the lockfile for another package manager, keeping the locked versions",
+			mutating: true,
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				from := flags.String("from", "", "lockfile format to read (default the lockfile present): berry, yarn-classic, npm, pnpm")
+				to := flags.String("to", "", "lockfile format to write: berry, yarn-classic, npm, pnpm")
+				readMetadata := metadataFlags(flags)
+				return func(env *environment, args []string) error {
+					return convert(*from, *to, readMetadata())
+				}
+			},
+		},
+		{
+			name:    "deprecated",
+			summary: "report locked package versions that are deprecated",
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				strict := flags.Bool("strict", false, "fail when deprecated packages are locked")
+				useYarn := flags.Bool("yarn", false, "read deprecations from yarn npm audit (yarn 4)")
+				return func(env *environment, args []string) error {
+					if !deprecated(env.ctx, env.runner, *useYarn) && *strict {
+						return fmt.Errorf("deprecated packages found")
+					}
+
+					return nil
+				}
+			},
+		},
+		{
+			name:    "diff",
+			args:    "<old-yarn.lock> <new-yarn.lock> | <git-ref>",
+			summary: "compare two lockfiles at the package level",
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				return func(env *environment, args []string) error {
+					return diff(args)
+				}
+			},
+		},
+		{
+			name:     "fix",
+			args:     "package-name safe-version-request",
+			summary:  "fix the lock entries of a package according to the given safe versions",
+			mutating: true,
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				return func(env *environment, args []string) error {
+					if len(args) < 2 {
+						return fmt.Errorf("insufficient arguments, expected package-name safe-version-request")
+					}
+
+					request, err := semver.ParseRequest(strings.Join(args[1:], " "))
+					if err != nil {
+						return fmt.Errorf("invalid safe-version-request: %v", err)
+					}
+
+					lock := mustReadLock()
+					lock.Fix(args[0], request)
+					recordSuggestions(lock, "suggested by gnarl fix")
+					mustSaveLock(lock)
+					return nil
+				}
+			},
+		},
+		{
+			name:       "help",
+			args:       "[verb]",
+			summary:    "print version and help",
+			standalone: true,
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				return func(env *environment, args []string) error {
+					return help(args)
+				}
+			},
+		},
+		{
+			name:    "licenses",
+			summary: "list the licenses of the locked packages and enforce the license policy",
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				readMetadata := metadataFlags(flags)
+				return func(env *environment, args []string) error {
+					return licenses(mustReadLock(), readMetadata())
+				}
+			},
+		},
+		{
+			name:     "merge",
+			args:     "[<base> <ours> <theirs>]",
+			summary:  "merge yarn.lock versions, usable as git merge driver",
+			mutating: true,
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				return func(env *environment, args []string) error {
+					return merge(args)
+				}
+			},
+		},
+		{
+			name:     "reset",
+			args:     "package-patterns...",
+			summary:  "remove the lock entries of packages, so that yarn install will update them",
+			mutating: true,
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				transitive := flags.Bool("transitive", false, "also reset the dependencies nothing else depends on")
+				return func(env *environment, args []string) error {
+					if len(args) == 0 {
+						return fmt.Errorf("insufficient arguments, expected package-patterns...")
+					}
+
+					var patterns []*yarn.ResetPattern
+					for _, arg := range args {
+						pattern, err := yarn.ParseResetPattern(arg)
+						if err != nil {
+							return err
+						}
+
+						patterns = append(patterns, pattern)
+					}
+
+					lock := mustReadLock()
+					for _, pattern := range patterns {
+						lock.ResetMatching(pattern, *transitive)
+					}
+
+					mustSaveLock(lock)
+					return nil
+				}
+			},
+		},
+		{
+			name:     "shrink",
+			summary:  "DEPRECATED: join package version resolutions, removing old versions where possible",
+			mutating: true,
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				return func(env *environment, args []string) error {
+					lock := mustReadLock()
+					lock.Shrink()
+					mustSaveLock(lock)
+					return nil
+				}
+			},
+		},
+		{
+			name:     "upgrade",
+			args:     "package-patterns...",
+			summary:  "move lock entries to the newest published version satisfying their ranges",
+			mutating: true,
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				readMetadata := metadataFlags(flags)
+				return func(env *environment, args []string) error {
+					if len(args) == 0 {
+						return fmt.Errorf("insufficient arguments, expected package-patterns...")
+					}
+
+					metadata := readMetadata()
+					lock := mustReadLock()
+					for _, arg := range args {
+						pattern, err := yarn.ParseResetPattern(arg)
+						if err != nil {
+							return err
+						}
+
+						if err := lock.Upgrade(pattern, metadata); err != nil {
+							return err
+						}
+					}
+
+					mustSaveLock(lock)
+					return nil
+				}
+			},
+		},
+		{
+			name:    "verify-checksums",
+			summary: "check the archives in the yarn cache against the checksums in yarn.lock",
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				return func(env *environment, args []string) error {
+					return verifyChecksums(mustReadLock())
+				}
+			},
+		},
+		{
+			name:    "why",
+			args:    "package-pattern",
+			summary: "show the dependency chains leading to a package",
+			setup: func(flags *flag.FlagSet) func(env *environment, args []string) error {
+				limit := flags.Int("limit", 20, "maximum number of chains to show")
+				return func(env *environment, args []string) error {
+					if len(args) != 1 {
+						return fmt.Errorf("expected package-pattern")
+					}
+
+					if *limit < 1 {
+						return fmt.Errorf("--limit must be at least 1, got %d", *limit)
+					}
+
+					return why(mustReadLock(), args[0], *limit)
+				}
+			},
+		},
 	}
 }
 
-func audit(project *yarn.Package) bool {
-	out, err := exec.Command("yarn", "--version").Output()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	version := semver.MustParseVersion(string(out))
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	log.Print("yarn npm audit --recursive")
-	out, err = exec.Command("yarn", "npm", "audit", "--json", "--recursive").Output()
-	if err != nil && version.Major < 4 {
-		log.Fatal(err)
+	if err := execute(ctx, os.Args[1:]); err != nil {
+		errorLog.Fatal(err)
 	}
+}
 
-	advisories, err := yarn.ParseAudit(out, version)
+// execute runs the verb given by the command line arguments, without the program name.
+func execute(ctx context.Context, arguments []string) error {
+	invoked, err := parseCommandLine(arguments)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	lock := mustReadLock()
-
-	for _, advisory := range advisories {
-		request, err := semver.ParseRequest(advisory.PatchedVersions)
+	if !invoked.command.standalone {
+		config, err := readSettings(cwd)
 		if err != nil {
-			log.Fatalf("invalid safe-version-request: %v", err)
+			return err
 		}
 
-		lock.Fix(advisory.ModuleName, request)
-	}
+		if err := config.apply(invoked.flags, explicitFlags(invoked.globals, invoked.flags)); err != nil {
+			return err
+		}
 
-	if len(advisories) == 0 {
-		log.Print("all packages considered safe")
+		configuration = config
 	}
 
-	if version.Major < 4 {
-		check(project, lock)
-	}
+	env := &environment{ctx: ctx, runner: yarn.NewExecRunner(cwd)}
+	env.runner.Stderr = os.Stderr
+	configureYarn(env.runner)
+	configureOutput(env.runner)
 
-	return mustSaveLock(lock)
-}
-
-func check(project *yarn.Package, lock *yarn.Lock) {
-	dirty := false
-	for key, r := range project.Resolutions {
-		parts := strings.Split(key, "@")
-		npmPackage := parts[0]
-		request := "*"
-		switch len(parts) {
-		case 1:
-			if v, err := semver.ParseRequest(r); err == nil && !v.IsExact() {
-				dirty = true
-				log.Printf("unrestricted resolution for %s", npmPackage)
-			}
-		default:
-			request = parts[1]
-		}
-
-		if !lock.Has(npmPackage, request) {
-			dirty = true
-			log.Printf("superfluous resolution for %s", key)
-		}
+	if invoked.command.project {
+		env.project = mustReadPackage()
 	}
 
-	if !dirty {
-		log.Print("all resolutions good")
-	}
+	return invoked.run(env, invoked.args)
 }
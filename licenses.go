@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"gnarl/yarn"
+	"log"
+	"sort"
+	"strings"
+)
+
+type licenseReport struct {
+	Licenses map[string]int        `json:"licenses"`
+	Packages []yarn.PackageLicense `json:"packages"`
+}
+
+// licenses lists the licenses of the locked packages and fails when the license policy of the configuration is
+// violated. Packages without license data fail an allow list, otherwise they are only reported.
+func licenses(lock *yarn.Lock, metadata *yarn.CachedMetadata) error {
+	var cache *yarn.Cache
+	if lock.Format().Manager() == yarn.Berry {
+		cache = mustReadCache()
+	}
+
+	if registry, ok := metadata.Upstream.(*yarn.Registry); ok {
+		registry.Full = true
+	}
+
+	policy, err := configuration.Policy.Licenses.policy()
+	if err != nil {
+		return err
+	}
+
+	packages := lock.Licenses(cache, metadata, policy)
+	summary := make(map[string]int)
+	var missing int
+	for _, license := range packages {
+		if license.Source == "" {
+			missing++
+			continue
+		}
+
+		summary[license.License]++
+	}
+
+	if !report(licenseReport{Licenses: summary, Packages: packages}) {
+		printLicenses(packages, summary)
+	}
+
+	if missing > 0 && len(policy.Allow) == 0 {
+		log.Printf("%d packages have no license data in the yarn cache, the lockfile or the registry metadata, they are not checked", missing)
+	}
+
+	var violations int
+	for _, license := range packages {
+		if license.Violation == "" {
+			continue
+		}
+
+		violations++
+		dependency := "direct dependency"
+		if !license.Direct {
+			dependency = "transitive dependency via " + strings.Join(license.Via, ", ")
+		}
+
+		if license.Source == "" {
+			errorLog.Printf("license policy violation: %s@%s, %s, has no license data in the yarn cache, the lockfile or the registry metadata", license.Name, license.Version, dependency)
+		} else if license.Violation == yarn.LicenseUnknown {
+			errorLog.Printf("license policy violation: %s@%s, %s, has no known license", license.Name, license.Version, dependency)
+		} else {
+			errorLog.Printf("license policy violation: %s@%s, %s, is %s which is %s", license.Name, license.Version, dependency, license.License, license.Violation)
+		}
+	}
+
+	if violations > 0 {
+		return fmt.Errorf("%d license policy violations", violations)
+	}
+
+	return nil
+}
+
+func printLicenses(packages []yarn.PackageLicense, summary map[string]int) {
+	for _, license := range packages {
+		description := describeLicense(license.License)
+		if license.Source == "" {
+			description = "without license data"
+		}
+
+		fmt.Fprintf(yarn.Output, "%s@%s %s\n", license.Name, license.Version, description)
+	}
+
+	var expressions []string
+	for expression := range summary {
+		expressions = append(expressions, expression)
+	}
+
+	sort.Slice(expressions, func(p, q int) bool {
+		if summary[expressions[p]] != summary[expressions[q]] {
+			return summary[expressions[p]] > summary[expressions[q]]
+		}
+
+		return expressions[p] < expressions[q]
+	})
+
+	for _, expression := range expressions {
+		log.Printf("%d packages %s", summary[expression], describeLicense(expression))
+	}
+}
+
+func describeLicense(license string) string {
+	if license == "" {
+		return "without a known license"
+	}
+
+	return license
+}
+
+func (s licenseSettings) policy() (yarn.LicensePolicy, error) {
+	policy := yarn.LicensePolicy{Allow: s.Allow, Deny: s.Deny}
+	for _, exception := range s.Exceptions {
+		pattern, err := yarn.ParseResetPattern(exception)
+		if err != nil {
+			return policy, err
+		}
+
+		policy.Exceptions = append(policy.Exceptions, pattern)
+	}
+
+	return policy, nil
+}
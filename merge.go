@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"gnarl/yarn"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+)
+
+// merge follows the git merge driver convention: the result is written over our version, conflicts fail.
+func merge(args []string) error {
+	var base, ours, theirs *yarn.Lock
+	var target string
+	var err error
+
+	switch len(args) {
+	case 0:
+		target = filepath.Join(cwd, lockfileName())
+		if base, ours, theirs, err = readConflictedLock(target); err != nil {
+			return err
+		}
+	case 3:
+		target = args[1]
+		if base, err = yarn.ReadLockFile(args[0]); err != nil {
+			log.Printf("ignoring merge base: %v", err)
+		}
+
+		if ours, err = yarn.ReadLockFile(args[1]); err != nil {
+			return err
+		}
+
+		if theirs, err = yarn.ReadLockFile(args[2]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("expected <base> <ours> <theirs> or no arguments")
+	}
+
+	merged, conflicts := yarn.MergeLocks(base, ours, theirs)
+	for _, conflict := range conflicts {
+		log.Printf("conflict %s", conflict)
+	}
+
+	if dryRun {
+		yarn.DiffLocks(ours, merged).Print()
+	} else if err := merged.WriteFile(target); err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%d conflicts in %s, kept our version", len(conflicts), target)
+	}
+
+	log.Printf("merged %s", target)
+	return nil
+}
+
+func readConflictedLock(path string) (*yarn.Lock, *yarn.Lock, *yarn.Lock, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("cannot read %s: %v", path, err)
+	}
+
+	baseData, ourData, theirData, conflicted := yarn.SplitConflict(data)
+	if !conflicted {
+		return nil, nil, nil, fmt.Errorf("no conflict markers in %s", path)
+	}
+
+	base, err := yarn.ParseLock(baseData)
+	if err != nil {
+		log.Printf("ignoring merge base: %v", err)
+	}
+
+	ours, err := yarn.ParseLock(ourData)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("cannot parse our side: %v", err)
+	}
+
+	theirs, err := yarn.ParseLock(theirData)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("cannot parse their side: %v", err)
+	}
+
+	return base, ours, theirs, nil
+}
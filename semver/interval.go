@@ -0,0 +1,228 @@
+package semver
+
+import "sort"
+
+type bound struct {
+	version   Version
+	inclusive bool
+	unbounded bool
+}
+
+type interval struct {
+	lower, upper bound
+}
+
+func (v *Version) Compare(other *Version) int {
+	switch {
+	case v.Major != other.Major:
+		return compareInt(v.Major, other.Major)
+	case v.Minor != other.Minor:
+		return compareInt(v.Minor, other.Minor)
+	case v.Patch != other.Patch:
+		return compareInt(v.Patch, other.Patch)
+	case v.Pre == other.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case other.Pre == "":
+		return -1
+	case v.Pre < other.Pre:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func compareInt(p, q int) int {
+	if p < q {
+		return -1
+	}
+
+	return 1
+}
+
+func (r *RequestFactor) interval() interval {
+	v := r.Version
+	from := bound{version: v, inclusive: true}
+	everything := interval{lower: bound{inclusive: true}, upper: bound{unbounded: true}}
+
+	switch r.Constraint {
+	case Exact:
+		return interval{lower: from, upper: from}
+	case MatchMinor:
+		return interval{lower: from, upper: bound{version: Version{Major: v.Major, Minor: v.Minor + 1}}}
+	case MatchMajor:
+		return interval{lower: from, upper: bound{version: Version{Major: v.Major + 1}}}
+	case AtLeast:
+		return interval{lower: from, upper: everything.upper}
+	case Greater:
+		return interval{lower: bound{version: v}, upper: everything.upper}
+	case AtMost:
+		return interval{lower: everything.lower, upper: from}
+	case Less:
+		return interval{lower: everything.lower, upper: bound{version: v}}
+	default:
+		return everything
+	}
+}
+
+// compareLower orders lower bounds, an exclusive bound starting after an inclusive one.
+func compareLower(p, q bound) int {
+	if c := p.version.Compare(&q.version); c != 0 {
+		return c
+	}
+
+	switch {
+	case p.inclusive == q.inclusive:
+		return 0
+	case p.inclusive:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// compareUpper orders upper bounds, an exclusive bound ending before an inclusive one.
+func compareUpper(p, q bound) int {
+	switch {
+	case p.unbounded && q.unbounded:
+		return 0
+	case p.unbounded:
+		return 1
+	case q.unbounded:
+		return -1
+	}
+
+	if c := p.version.Compare(&q.version); c != 0 {
+		return c
+	}
+
+	switch {
+	case p.inclusive == q.inclusive:
+		return 0
+	case p.inclusive:
+		return 1
+	default:
+		return -1
+	}
+}
+
+func (i interval) empty() bool {
+	if i.upper.unbounded {
+		return false
+	}
+
+	c := i.lower.version.Compare(&i.upper.version)
+	return c > 0 || c == 0 && !(i.lower.inclusive && i.upper.inclusive)
+}
+
+func (i interval) intersect(other interval) interval {
+	if compareLower(other.lower, i.lower) > 0 {
+		i.lower = other.lower
+	}
+
+	if compareUpper(other.upper, i.upper) < 0 {
+		i.upper = other.upper
+	}
+
+	return i
+}
+
+func (r *Request) intervals() []interval {
+	var intervals []interval
+
+	for _, term := range r.terms {
+		i := interval{lower: bound{inclusive: true}, upper: bound{unbounded: true}}
+		for _, factor := range term {
+			i = i.intersect(factor.interval())
+		}
+
+		if !i.empty() {
+			intervals = append(intervals, i)
+		}
+	}
+
+	return union(intervals)
+}
+
+func union(intervals []interval) []interval {
+	sort.Slice(intervals, func(p, q int) bool { return compareLower(intervals[p].lower, intervals[q].lower) < 0 })
+
+	var merged []interval
+	for _, i := range intervals {
+		if n := len(merged); n > 0 && touches(merged[n-1].upper, i.lower) {
+			if compareUpper(i.upper, merged[n-1].upper) > 0 {
+				merged[n-1].upper = i.upper
+			}
+
+			continue
+		}
+
+		merged = append(merged, i)
+	}
+
+	return merged
+}
+
+func touches(upper, lower bound) bool {
+	if upper.unbounded {
+		return true
+	}
+
+	c := upper.version.Compare(&lower.version)
+	return c > 0 || c == 0 && (upper.inclusive || lower.inclusive)
+}
+
+func complement(intervals []interval) []interval {
+	var result []interval
+
+	lower := bound{inclusive: true}
+	for _, i := range intervals {
+		gap := interval{lower: lower, upper: bound{version: i.lower.version, inclusive: !i.lower.inclusive}}
+		if !gap.empty() {
+			result = append(result, gap)
+		}
+
+		if i.upper.unbounded {
+			return result
+		}
+
+		lower = bound{version: i.upper.version, inclusive: !i.upper.inclusive}
+	}
+
+	return append(result, interval{lower: lower, upper: bound{unbounded: true}})
+}
+
+func (i interval) term() RequestTerm {
+	l, u := i.lower, i.upper
+
+	switch {
+	case !u.unbounded && l.inclusive && u.inclusive && l.version.Compare(&u.version) == 0:
+		return RequestTerm{{Constraint: Exact, Version: l.version}}
+	case !u.unbounded && l.inclusive && !u.inclusive && l.version.Major > 0 &&
+		u.version.Compare(&Version{Major: l.version.Major + 1}) == 0:
+		return RequestTerm{{Constraint: MatchMajor, Version: l.version}}
+	}
+
+	var term RequestTerm
+	switch {
+	case !l.inclusive:
+		term = append(term, RequestFactor{Constraint: Greater, Version: l.version})
+	case l.version.Compare(&Version{}) != 0:
+		term = append(term, RequestFactor{Constraint: AtLeast, Version: l.version})
+	}
+
+	switch {
+	case u.unbounded:
+	case u.inclusive:
+		term = append(term, RequestFactor{Constraint: AtMost, Version: u.version})
+	default:
+		term = append(term, RequestFactor{Constraint: Less, Version: u.version})
+	}
+
+	if len(term) == 0 {
+		term = append(term, RequestFactor{Constraint: Any})
+	}
+
+	return term
+}
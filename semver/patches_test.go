@@ -0,0 +1,31 @@
+package semver_test
+
+import (
+	"gnarl/semver"
+	"testing"
+)
+
+func TestPatches(t *testing.T) {
+	for vulnerable, expected := range map[string]string{
+		"<1.2.3":                              "^1.2.3",
+		"<=1.2.3":                             ">1.2.3 <2.0.0",
+		">=2.0.0 <2.4.1 || >=3.0.0 <3.1.2":    "^2.4.1 || ^3.1.2",
+		">=3.0.0 <3.1.2 || >=2.0.0 <2.4.1":    "^2.4.1 || ^3.1.2",
+		"<0.5.0":                              ">=0.5.0 <1.0.0",
+		">=1.1.0 <1.3.0":                      ">=1.0.0 <1.1.0 || ^1.3.0",
+		"=1.2.3":                              ">=1.0.0 <1.2.3 || >1.2.3 <2.0.0",
+		">=1.0.0 <1.5.0 || >=1.5.0 <2.0.0":    "<0.0.0",
+		"*":                                   "<0.0.0",
+		">=4.0.0":                             "<0.0.0",
+		">=4.2.0":                             ">=4.0.0 <4.2.0",
+		"<2.0.0":                              "<0.0.0",
+		">1.0.0 <=1.0.5 || >=2.0.0-rc <2.0.1": "=1.0.0 || >1.0.5 <2.0.0-rc || ^2.0.1",
+		"<=1.2.3-beta.1":                      ">1.2.3-beta.1 <2.0.0",
+		"<1.2.3-beta.1":                       "^1.2.3-beta.1",
+	} {
+		actual := semver.MustParseRequest(vulnerable).Patches().String()
+		if actual != expected {
+			t.Errorf("Patches of %s: expected %s, got %s", vulnerable, expected, actual)
+		}
+	}
+}
@@ -122,12 +122,15 @@ terms:
 }
 
 func (r *Request) Patches() *Request {
-	var patches []RequestTerm
+	vulnerable := r.intervals()
+	safe := complement(vulnerable)
 
-	for _, term := range r.terms {
-		for _, factor := range term {
-			if factor.Constraint == Less {
-				patches = append(patches, RequestTerm{RequestFactor{Constraint: MatchMajor, Version: factor.Version}})
+	var patches []RequestTerm
+	for _, major := range affectedMajors(vulnerable) {
+		within := interval{lower: bound{version: Version{Major: major}, inclusive: true}, upper: bound{version: Version{Major: major + 1}}}
+		for _, i := range safe {
+			if patch := i.intersect(within); !patch.empty() {
+				patches = append(patches, patch.term())
 			}
 		}
 	}
@@ -139,6 +142,42 @@ func (r *Request) Patches() *Request {
 	return &Request{terms: patches}
 }
 
+func affectedMajors(intervals []interval) []int {
+	var majors []int
+
+	for _, i := range intervals {
+		last := i.lower.version.Major
+		switch {
+		case i.upper.unbounded:
+		case !i.upper.inclusive && i.upper.version.Minor == 0 && i.upper.version.Patch == 0:
+			last = i.upper.version.Major - 1
+		default:
+			last = i.upper.version.Major
+		}
+
+		for major := i.lower.version.Major; major <= last; major++ {
+			if n := len(majors); n == 0 || majors[n-1] < major {
+				majors = append(majors, major)
+			}
+		}
+	}
+
+	return majors
+}
+
+// NamesPrerelease tells whether a version of the request has a prerelease tag, like >=1.0.0-beta.1.
+func (r *Request) NamesPrerelease() bool {
+	for _, term := range r.terms {
+		for _, factor := range term {
+			if factor.Pre != "" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func (r *Request) IsExact() bool {
 	return len(r.terms) == 1 && len(r.terms[0]) == 1 && r.terms[0][0].Constraint == Exact
 }
@@ -190,6 +229,21 @@ func (r *Request) Overlaps(other *Request) (bool, *Version) {
 	return false, &fromVersions[0]
 }
 
+func (r *Request) Boundaries() []Version {
+	var versions []Version
+
+	for _, term := range r.terms {
+		for _, factor := range term {
+			if factor.Constraint != Any {
+				successor := Version{Major: factor.Major, Minor: factor.Minor, Patch: factor.Patch + 1}
+				versions = append(versions, factor.Version, successor)
+			}
+		}
+	}
+
+	return versions
+}
+
 func (r *Request) fromVersions() []Version {
 	var versions []Version
 
@@ -63,6 +63,19 @@ func (v *Version) AtLeast() *Request {
 	return &Request{terms: []RequestTerm{factors}}
 }
 
+func (v *Version) Distance(other *Version) string {
+	switch {
+	case v.Major != other.Major:
+		return "major"
+	case v.Minor != other.Minor:
+		return "minor"
+	case v.Patch != other.Patch:
+		return "patch"
+	default:
+		return "prerelease"
+	}
+}
+
 func (v *Version) String() string {
 	return fmt.Sprintf("%d.%d.%d%s", v.Major, v.Minor, v.Patch, v.Pre)
 }
@@ -0,0 +1,28 @@
+_gnarl() {
+    local cur=${COMP_WORDS[COMP_CWORD]} verb= word opts
+    for word in "${COMP_WORDS[@]:1:COMP_CWORD-1}"; do
+        case $word in -*) ;; *) verb=$word; break ;; esac
+    done
+    case $verb in
+        auto) opts="--apply-suggestions --cross-check --cwd --dry-run --ignore --json --max-iterations --offline --quiet --severity --steps --timeout --verbose --yarn --yarn-path" ;;
+        audit) opts="--apply-suggestions --cross-check --cwd --dry-run --ignore --json --offline --quiet --severity --timeout --verbose --yarn --yarn-path" ;;
+        cache) opts="--cwd --dry-run --json --quiet --verbose --yarn-path" ;;
+        check) opts="--cwd --dry-run --json --offline --prune --quiet --verbose --yarn-path" ;;
+        completion) opts="--cwd --json --quiet --verbose --yarn-path" ;;
+        convert) opts="--cache --cwd --dry-run --from --json --offline --quiet --to --verbose --yarn-path" ;;
+        deprecated) opts="--cwd --json --quiet --strict --verbose --yarn --yarn-path" ;;
+        diff) opts="--cwd --json --quiet --verbose --yarn-path" ;;
+        fix) opts="--cwd --dry-run --json --quiet --verbose --yarn-path" ;;
+        help) opts="--cwd --json --quiet --verbose --yarn-path" ;;
+        licenses) opts="--cache --cwd --json --offline --quiet --verbose --yarn-path" ;;
+        merge) opts="--cwd --dry-run --json --quiet --verbose --yarn-path" ;;
+        reset) opts="--cwd --dry-run --json --quiet --transitive --verbose --yarn-path" ;;
+        shrink) opts="--cwd --dry-run --json --quiet --verbose --yarn-path" ;;
+        upgrade) opts="--cache --cwd --dry-run --json --offline --quiet --verbose --yarn-path" ;;
+        verify-checksums) opts="--cwd --json --quiet --verbose --yarn-path" ;;
+        why) opts="--cwd --json --limit --quiet --verbose --yarn-path" ;;
+        *) opts="auto audit cache check completion convert deprecated diff fix help licenses merge reset shrink upgrade verify-checksums why --cwd --verbose --quiet --json --yarn-path" ;;
+    esac
+    COMPREPLY=($(compgen -W "$opts" -- "$cur"))
+}
+complete -o default -F _gnarl gnarl
@@ -0,0 +1,142 @@
+complete -c gnarl -n __fish_use_subcommand -a auto -d 'repeat yarn install, yarn dedupe and gnarl audit until yarn.lock is stable (default)'
+complete -c gnarl -n __fish_use_subcommand -a audit -d 'reset packages with a safe fix, suggest resolutions for the others and check resolutions'
+complete -c gnarl -n __fish_use_subcommand -a cache -d 'remove archives yarn.lock no longer refers to from the yarn cache, or list entries without one'
+complete -c gnarl -n __fish_use_subcommand -a check -d 'check whether resolutions are still in use, safe, up to date and needed'
+complete -c gnarl -n __fish_use_subcommand -a completion -d 'print a shell completion script'
+complete -c gnarl -n __fish_use_subcommand -a convert -d 'write the lockfile for another package manager, keeping the locked versions'
+complete -c gnarl -n __fish_use_subcommand -a deprecated -d 'report locked package versions that are deprecated'
+complete -c gnarl -n __fish_use_subcommand -a diff -d 'compare two lockfiles at the package level'
+complete -c gnarl -n __fish_use_subcommand -a fix -d 'fix the lock entries of a package according to the given safe versions'
+complete -c gnarl -n __fish_use_subcommand -a help -d 'print version and help'
+complete -c gnarl -n __fish_use_subcommand -a licenses -d 'list the licenses of the locked packages and enforce the license policy'
+complete -c gnarl -n __fish_use_subcommand -a merge -d 'merge yarn.lock versions, usable as git merge driver'
+complete -c gnarl -n __fish_use_subcommand -a reset -d 'remove the lock entries of packages, so that yarn install will update them'
+complete -c gnarl -n __fish_use_subcommand -a shrink -d 'DEPRECATED: join package version resolutions, removing old versions where possible'
+complete -c gnarl -n __fish_use_subcommand -a upgrade -d 'move lock entries to the newest published version satisfying their ranges'
+complete -c gnarl -n __fish_use_subcommand -a verify-checksums -d 'check the archives in the yarn cache against the checksums in yarn.lock'
+complete -c gnarl -n __fish_use_subcommand -a why -d 'show the dependency chains leading to a package'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l apply-suggestions -d 'add the suggested resolutions to package.json'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l cross-check -d 'compare npm patched versions with a local advisory database'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l dry-run -d 'print the lockfile changes instead of saving them'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l ignore -d 'comma separated advisory ids and package patterns to ignore'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l max-iterations -d 'give up when yarn.lock is not stable after this many iterations (0 for no limit)'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l offline -d 'audit against a local advisory database instead of the npm registry'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l severity -d 'ignore advisories below this severity: info, low, moderate, high, critical'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l steps -d 'comma separated steps of an iteration: install, dedupe, audit'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l timeout -d 'abort yarn commands running longer than this duration'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l yarn -d 'audit with yarn npm audit instead of the npm bulk advisory endpoint'
+complete -c gnarl -n '__fish_seen_subcommand_from auto' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from audit' -l apply-suggestions -d 'add the suggested resolutions to package.json'
+complete -c gnarl -n '__fish_seen_subcommand_from audit' -l cross-check -d 'compare npm patched versions with a local advisory database'
+complete -c gnarl -n '__fish_seen_subcommand_from audit' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from audit' -l dry-run -d 'print the lockfile changes instead of saving them'
+complete -c gnarl -n '__fish_seen_subcommand_from audit' -l ignore -d 'comma separated advisory ids and package patterns to ignore'
+complete -c gnarl -n '__fish_seen_subcommand_from audit' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from audit' -l offline -d 'audit against a local advisory database instead of the npm registry'
+complete -c gnarl -n '__fish_seen_subcommand_from audit' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from audit' -l severity -d 'ignore advisories below this severity: info, low, moderate, high, critical'
+complete -c gnarl -n '__fish_seen_subcommand_from audit' -l timeout -d 'abort yarn commands running longer than this duration'
+complete -c gnarl -n '__fish_seen_subcommand_from audit' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from audit' -l yarn -d 'audit with yarn npm audit instead of the npm bulk advisory endpoint'
+complete -c gnarl -n '__fish_seen_subcommand_from audit' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from cache' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from cache' -l dry-run -d 'print the lockfile changes instead of saving them'
+complete -c gnarl -n '__fish_seen_subcommand_from cache' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from cache' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from cache' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from cache' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from check' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from check' -l dry-run -d 'print the lockfile changes instead of saving them'
+complete -c gnarl -n '__fish_seen_subcommand_from check' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from check' -l offline -d 'check against a local advisory database instead of the npm registry'
+complete -c gnarl -n '__fish_seen_subcommand_from check' -l prune -d 'remove the resolutions that are no longer needed from package.json'
+complete -c gnarl -n '__fish_seen_subcommand_from check' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from check' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from check' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from completion' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from completion' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from completion' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from completion' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from completion' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from convert' -l cache -d 'registry metadata cache directory (default .gnarl/registry)'
+complete -c gnarl -n '__fish_seen_subcommand_from convert' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from convert' -l dry-run -d 'print the lockfile changes instead of saving them'
+complete -c gnarl -n '__fish_seen_subcommand_from convert' -l from -d 'lockfile format to read (default the lockfile present): berry, yarn-classic, npm, pnpm'
+complete -c gnarl -n '__fish_seen_subcommand_from convert' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from convert' -l offline -d 'only use cached registry metadata'
+complete -c gnarl -n '__fish_seen_subcommand_from convert' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from convert' -l to -d 'lockfile format to write: berry, yarn-classic, npm, pnpm'
+complete -c gnarl -n '__fish_seen_subcommand_from convert' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from convert' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from deprecated' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from deprecated' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from deprecated' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from deprecated' -l strict -d 'fail when deprecated packages are locked'
+complete -c gnarl -n '__fish_seen_subcommand_from deprecated' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from deprecated' -l yarn -d 'read deprecations from yarn npm audit (yarn 4)'
+complete -c gnarl -n '__fish_seen_subcommand_from deprecated' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from diff' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from diff' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from diff' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from diff' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from diff' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from fix' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from fix' -l dry-run -d 'print the lockfile changes instead of saving them'
+complete -c gnarl -n '__fish_seen_subcommand_from fix' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from fix' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from fix' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from fix' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from help' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from help' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from help' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from help' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from help' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from licenses' -l cache -d 'registry metadata cache directory (default .gnarl/registry)'
+complete -c gnarl -n '__fish_seen_subcommand_from licenses' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from licenses' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from licenses' -l offline -d 'only use cached registry metadata'
+complete -c gnarl -n '__fish_seen_subcommand_from licenses' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from licenses' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from licenses' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from merge' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from merge' -l dry-run -d 'print the lockfile changes instead of saving them'
+complete -c gnarl -n '__fish_seen_subcommand_from merge' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from merge' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from merge' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from merge' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from reset' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from reset' -l dry-run -d 'print the lockfile changes instead of saving them'
+complete -c gnarl -n '__fish_seen_subcommand_from reset' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from reset' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from reset' -l transitive -d 'also reset the dependencies nothing else depends on'
+complete -c gnarl -n '__fish_seen_subcommand_from reset' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from reset' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from shrink' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from shrink' -l dry-run -d 'print the lockfile changes instead of saving them'
+complete -c gnarl -n '__fish_seen_subcommand_from shrink' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from shrink' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from shrink' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from shrink' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from upgrade' -l cache -d 'registry metadata cache directory (default .gnarl/registry)'
+complete -c gnarl -n '__fish_seen_subcommand_from upgrade' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from upgrade' -l dry-run -d 'print the lockfile changes instead of saving them'
+complete -c gnarl -n '__fish_seen_subcommand_from upgrade' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from upgrade' -l offline -d 'only use cached registry metadata'
+complete -c gnarl -n '__fish_seen_subcommand_from upgrade' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from upgrade' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from upgrade' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from verify-checksums' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from verify-checksums' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from verify-checksums' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from verify-checksums' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from verify-checksums' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
+complete -c gnarl -n '__fish_seen_subcommand_from why' -l cwd -d 'run in this project directory'
+complete -c gnarl -n '__fish_seen_subcommand_from why' -l json -d 'print results as json'
+complete -c gnarl -n '__fish_seen_subcommand_from why' -l limit -d 'maximum number of chains to show'
+complete -c gnarl -n '__fish_seen_subcommand_from why' -l quiet -d 'only report errors and results'
+complete -c gnarl -n '__fish_seen_subcommand_from why' -l verbose -d 'stream the output of yarn'
+complete -c gnarl -n '__fish_seen_subcommand_from why' -l yarn-path -d 'run this yarn binary or release instead of yarn from the PATH'
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"gnarl/yarn"
+	"log"
+	"strings"
+)
+
+// why prints the dependency chains leading to the packages matching the pattern.
+func why(lock *yarn.Lock, arg string, limit int) error {
+	pattern, err := yarn.ParseResetPattern(arg)
+	if err != nil {
+		return err
+	}
+
+	chains, truncated := lock.Why(pattern, limit)
+	if report(chains) {
+		return nil
+	}
+
+	if len(chains) == 0 {
+		return fmt.Errorf("no locked package matches %s", arg)
+	}
+
+	for _, chain := range chains {
+		fmt.Println(strings.Join(chain, " > "))
+	}
+
+	if truncated {
+		log.Printf("more than %d chains, showing the shortest", limit)
+	}
+
+	return nil
+}
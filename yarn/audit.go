@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"gnarl/semver"
 	"io"
+	"log"
 	"strings"
 )
 
@@ -13,8 +14,25 @@ type Audit struct {
 }
 
 type Advisory struct {
-	ModuleName      string `json:"module_name,omitempty"`
-	PatchedVersions string `json:"patched_versions,omitempty"`
+	Id                 int    `json:"id,omitempty"`
+	GithubAdvisoryId   string `json:"github_advisory_id,omitempty"`
+	Title              string `json:"title,omitempty"`
+	Severity           string `json:"severity,omitempty"`
+	Url                string `json:"url,omitempty"`
+	ModuleName         string `json:"module_name,omitempty"`
+	VulnerableVersions string `json:"vulnerable_versions,omitempty"`
+	PatchedVersions    string `json:"patched_versions,omitempty"`
+}
+
+func (advisory Advisory) Identifier() string {
+	switch {
+	case advisory.GithubAdvisoryId != "":
+		return advisory.GithubAdvisoryId
+	case advisory.Id != 0:
+		return fmt.Sprintf("%d", advisory.Id)
+	default:
+		return advisory.Url
+	}
 }
 
 func ParseAudit(output []byte, version *semver.Version) ([]Advisory, error) {
@@ -55,14 +73,44 @@ type Yarn4Advisory struct {
 
 type Yarn4AdvisoryChildren struct {
 	Id                 interface{} `json:"ID"`
+	Issue              string      `json:"Issue"`
+	Url                string      `json:"URL"`
+	Severity           string      `json:"Severity"`
 	VulnerableVersions string      `json:"Vulnerable Versions"`
+	TreeVersions       []string    `json:"Tree Versions"`
+	Dependents         []string    `json:"Dependents"`
+}
+
+func (yarn4Advisory Yarn4Advisory) ToAdvisory() (Advisory, error) {
+	vulnerable, err := semver.ParseRequest(yarn4Advisory.Children.VulnerableVersions)
+	if err != nil {
+		return Advisory{}, fmt.Errorf("invalid vulnerable versions %s: %v", yarn4Advisory.Children.VulnerableVersions, err)
+	}
+
+	advisory := Advisory{
+		Title:              yarn4Advisory.Children.Issue,
+		Severity:           yarn4Advisory.Children.Severity,
+		Url:                yarn4Advisory.Children.Url,
+		ModuleName:         yarn4Advisory.ModuleName,
+		VulnerableVersions: yarn4Advisory.Children.VulnerableVersions,
+		PatchedVersions:    vulnerable.Patches().String(),
+	}
+
+	if id, ok := yarn4Advisory.Children.Id.(float64); ok {
+		advisory.Id = int(id)
+	}
+
+	advisory.GithubAdvisoryId = githubAdvisoryIdFromUrl(advisory.Url)
+
+	return advisory, nil
 }
 
-func (yarn4Advisory Yarn4Advisory) ToAdvisory() Advisory {
-	return Advisory{
-		ModuleName:      yarn4Advisory.ModuleName,
-		PatchedVersions: semver.MustParseRequest(yarn4Advisory.Children.VulnerableVersions).Patches().String(),
+func githubAdvisoryIdFromUrl(url string) string {
+	if loc := strings.Index(url, "/GHSA-"); loc >= 0 {
+		return url[loc+1:]
 	}
+
+	return ""
 }
 
 func ParseAuditYarn4(output []byte) ([]Advisory, error) {
@@ -85,7 +133,13 @@ func ParseAuditYarn4(output []byte) ([]Advisory, error) {
 			}
 		}
 
-		advisories = append(advisories, issue.ToAdvisory())
+		advisory, err := issue.ToAdvisory()
+		if err != nil {
+			log.Printf("skipping advisory %v for %s: %v", issue.Children.Id, issue.ModuleName, err)
+			continue
+		}
+
+		advisories = append(advisories, advisory)
 	}
 	return advisories, nil
 }
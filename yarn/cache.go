@@ -0,0 +1,309 @@
+package yarn
+
+import (
+	"crypto/sha512"
+	"fmt"
+	"gnarl/semver"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Cache is the folder berry keeps package archives in: the cacheFolder of the project, or the cache in the
+// globalFolder with enableGlobalCache.
+type Cache struct {
+	Folder string
+	Global bool
+}
+
+// NewCache locates the cache like yarn does. enableGlobalCache defaults to true since yarn 4; when the yarn version
+// is unknown, an existing project cache is used.
+func NewCache(config *Config, version *semver.Version) *Cache {
+	project := config.Path(".yarn/cache")
+	if config.CacheFolder != "" {
+		project = config.Path(config.CacheFolder)
+	}
+
+	global := config.EnableGlobalCache != nil && *config.EnableGlobalCache
+	if config.EnableGlobalCache == nil {
+		if version != nil {
+			global = version.Major >= 4
+		} else if _, err := os.Stat(project); err != nil {
+			global = true
+		}
+	}
+
+	if !global {
+		return &Cache{Folder: project}
+	}
+
+	folder := config.Path(config.GlobalFolder)
+	if folder == "" {
+		folder = defaultGlobalFolder()
+	}
+
+	return &Cache{Folder: filepath.Join(folder, "cache"), Global: true}
+}
+
+func defaultGlobalFolder() string {
+	if runtime.GOOS == "windows" {
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			return filepath.Join(local, "Yarn", "Berry")
+		}
+	}
+
+	if data := os.Getenv("XDG_DATA_HOME"); data != "" {
+		return filepath.Join(data, "yarn", "berry")
+	}
+
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".yarn", "berry")
+}
+
+// Archive finds the archive of a lock entry. Yarn 4 names archives after the locator and the start of the checksum,
+// yarn 2 and 3 after the locator and the cache key.
+func (cache *Cache) Archive(resolution Resolution, cacheKey string) (string, bool) {
+	slug, prefix := slugifyLocator(resolution.Resolution)
+
+	var names []string
+	if _, hash := splitChecksum(resolution.Checksum); len(hash) >= 10 {
+		names = append(names, slug+"-"+hash[:10]+".zip")
+	}
+
+	if cacheKey != "" {
+		names = append(names, slug+"-"+cacheKey+".zip")
+	}
+
+	for _, name := range names {
+		if info, err := os.Stat(filepath.Join(cache.Folder, name)); err == nil && !info.IsDir() {
+			return filepath.Join(cache.Folder, name), true
+		}
+	}
+
+	// Locator hashes of exotic references may be computed from a reference yarn normalized differently.
+	matches, _ := filepath.Glob(filepath.Join(cache.Folder, globEscape(prefix)+"-*.zip"))
+	if len(matches) == 1 {
+		return matches[0], true
+	}
+
+	return "", false
+}
+
+// Archives lists the archives in the cache.
+func (cache *Cache) Archives() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(cache.Folder, "*.zip"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(matches)
+	return matches, nil
+}
+
+var globSpecial = regexp.MustCompile(`[*?\[\\]`)
+
+func globEscape(pattern string) string {
+	return globSpecial.ReplaceAllString(pattern, `\$0`)
+}
+
+// Unreferenced lists the archives in the cache no lock entry refers to. Yarn names archives of references without a
+// version, like patches, after the package and the protocol only, so these are kept for any entry like that.
+func (cache *Cache) Unreferenced(lock *Lock) ([]string, error) {
+	archives, err := cache.Archives()
+	if err != nil {
+		return nil, err
+	}
+
+	var prefixes []string
+	for _, resolution := range lock.archived() {
+		slug, prefix := slugifyLocator(resolution.Resolution)
+		prefixes = append(prefixes, slug+"-")
+		if _, reference := splitLocator(resolution.Resolution); !strings.HasSuffix(prefix, "-"+strings.TrimPrefix(reference, "npm:")) {
+			prefixes = append(prefixes, prefix+"-")
+		}
+	}
+
+	var unreferenced []string
+	for _, archive := range archives {
+		referenced := false
+		for _, prefix := range prefixes {
+			referenced = referenced || strings.HasPrefix(filepath.Base(archive), prefix)
+		}
+
+		if !referenced {
+			unreferenced = append(unreferenced, archive)
+		}
+	}
+
+	return unreferenced, nil
+}
+
+// Missing lists the locators of the lock entries without an archive in the cache.
+func (cache *Cache) Missing(lock *Lock) []string {
+	var missing []string
+	for _, resolution := range lock.archived() {
+		if _, ok := cache.Archive(resolution, lock.CacheKey()); !ok {
+			missing = append(missing, resolution.Resolution)
+		}
+	}
+
+	return missing
+}
+
+// hasArchive tells whether yarn fetches the locator into the cache, workspaces and links are used in place.
+func hasArchive(locator string) bool {
+	_, reference := splitLocator(locator)
+	for _, protocol := range []string{"workspace:", "link:", "portal:"} {
+		if strings.HasPrefix(reference, protocol) {
+			return false
+		}
+	}
+
+	return locator != ""
+}
+
+// splitChecksum separates the cache key yarn 4 prefixes checksums with from the hash.
+func splitChecksum(checksum string) (string, string) {
+	if loc := strings.Index(checksum, "/"); loc >= 0 {
+		return checksum[:loc], checksum[loc+1:]
+	}
+
+	return "", checksum
+}
+
+var rangeProtocol = regexp.MustCompile(`^([^#:]*:)?([^#]*)`)
+
+// slugifyLocator returns the name yarn gives the archives of a locator, like lodash-npm-4.17.21-6382451519, without
+// the suffix, and the same without the locator hash.
+func slugifyLocator(locator string) (string, string) {
+	name, reference := splitLocator(locator)
+	scope := ""
+	if strings.HasPrefix(name, "@") {
+		if loc := strings.Index(name, "/"); loc >= 0 {
+			scope, name = name[1:loc], name[loc+1:]
+		}
+	}
+
+	match := rangeProtocol.FindStringSubmatch(reference)
+	protocol := "exotic"
+	if match[1] != "" {
+		protocol = strings.TrimSuffix(match[1], ":")
+	}
+
+	human := protocol
+	if version, err := semver.ParseVersion(match[2]); err == nil && version.String() == match[2] {
+		human += "-" + match[2]
+	}
+
+	ident := name
+	if scope != "" {
+		ident = "@" + scope + "-" + name
+	}
+
+	identHash := makeHash(scope, name)
+	prefix := ident + "-" + human
+	return prefix + "-" + makeHash(identHash, reference)[:10], prefix
+}
+
+// makeHash is the sha512 of the concatenated parts, like the identifier and locator hashes of yarn.
+func makeHash(parts ...string) string {
+	return fmt.Sprintf("%x", sha512.Sum512([]byte(strings.Join(parts, ""))))
+}
+
+// ArchiveChecksum computes the checksum berry records for an archive, the sha512 of the file.
+func ArchiveChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	hash := sha512.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("cannot read %s: %v", filepath.Base(path), err)
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// CacheKey is the cache key of the lockfile, which yarn 2 and 3 name archives after.
+func (lock *Lock) CacheKey() string {
+	return lock.resolutions["__metadata"].CacheKey
+}
+
+// ChecksumProblem is a lock entry whose archive does not match its checksum, is missing or has no checksum.
+type ChecksumProblem struct {
+	Locator  string `json:"locator"`
+	Archive  string `json:"archive,omitempty"`
+	Problem  string `json:"problem"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+const (
+	ChecksumMismatch = "mismatch"
+	ArchiveMissing   = "missing"
+	ChecksumMissing  = "no checksum"
+)
+
+func (problem ChecksumProblem) String() string {
+	switch problem.Problem {
+	case ChecksumMismatch:
+		return fmt.Sprintf("%s: %s has checksum %s, expected %s", problem.Locator, filepath.Base(problem.Archive), problem.Actual, problem.Expected)
+	case ArchiveMissing:
+		return fmt.Sprintf("%s: no archive in the cache", problem.Locator)
+	default:
+		return fmt.Sprintf("%s: no checksum in the lockfile", problem.Locator)
+	}
+}
+
+// VerifyChecksums recomputes the checksums of the archives of the lock entries, without network access. The second
+// result is the number of archives that match.
+func (cache *Cache) VerifyChecksums(lock *Lock) ([]ChecksumProblem, int, error) {
+	var problems []ChecksumProblem
+	verified := 0
+	for _, resolution := range lock.archived() {
+		if resolution.Checksum == "" {
+			problems = append(problems, ChecksumProblem{Locator: resolution.Resolution, Problem: ChecksumMissing})
+			continue
+		}
+
+		archive, ok := cache.Archive(resolution, lock.CacheKey())
+		if !ok {
+			problems = append(problems, ChecksumProblem{Locator: resolution.Resolution, Problem: ArchiveMissing})
+			continue
+		}
+
+		actual, err := ArchiveChecksum(archive)
+		if err != nil {
+			return nil, verified, err
+		}
+
+		if _, expected := splitChecksum(resolution.Checksum); actual != expected {
+			problems = append(problems, ChecksumProblem{Locator: resolution.Resolution, Archive: archive, Problem: ChecksumMismatch, Expected: expected, Actual: actual})
+			continue
+		}
+
+		verified++
+	}
+
+	return problems, verified, nil
+}
+
+// archived lists the lock entries yarn keeps an archive of, by locator.
+func (lock *Lock) archived() []Resolution {
+	var resolutions []Resolution
+	for key, resolution := range lock.resolutions {
+		if key != "__metadata" && hasArchive(resolution.Resolution) {
+			resolutions = append(resolutions, resolution)
+		}
+	}
+
+	sort.Slice(resolutions, func(p, q int) bool { return resolutions[p].Resolution < resolutions[q].Resolution })
+	return resolutions
+}
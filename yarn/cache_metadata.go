@@ -0,0 +1,60 @@
+package yarn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CachedMetadata keeps registry metadata on disk, so that it can be used without network access.
+type CachedMetadata struct {
+	Directory string
+	Upstream  Metadata
+}
+
+func (cache *CachedMetadata) file(npmPackage string) string {
+	return filepath.Join(cache.Directory, strings.Replace(npmPackage, "/", "%2f", 1)+".json")
+}
+
+func (cache *CachedMetadata) Packument(npmPackage string) (*Packument, error) {
+	if cache.Upstream != nil {
+		packument, err := cache.Upstream.Packument(npmPackage)
+		if err == nil {
+			cache.store(npmPackage, packument)
+			return packument, nil
+		}
+
+		log.Printf("falling back to cached metadata: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(cache.file(npmPackage))
+	if err != nil {
+		return nil, fmt.Errorf("no cached metadata for %s: %v", npmPackage, err)
+	}
+
+	packument := Packument{}
+	if err := json.Unmarshal(data, &packument); err != nil {
+		return nil, fmt.Errorf("cannot deserialize cached metadata for %s: %v", npmPackage, err)
+	}
+
+	return &packument, nil
+}
+
+func (cache *CachedMetadata) store(npmPackage string, packument *Packument) {
+	data, err := json.Marshal(packument)
+	if err == nil {
+		err = os.MkdirAll(cache.Directory, os.ModePerm)
+	}
+
+	if err == nil {
+		err = ioutil.WriteFile(cache.file(npmPackage), data, 0644)
+	}
+
+	if err != nil {
+		log.Printf("cannot cache metadata for %s: %v", npmPackage, err)
+	}
+}
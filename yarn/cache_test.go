@@ -0,0 +1,123 @@
+package yarn
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSlugifyLocator(t *testing.T) {
+	for locator, expected := range map[string]string{
+		"lodash@npm:4.17.21":                      "lodash-npm-4.17.21-6382451519",
+		"@scope/left@npm:1.2.0":                   "@scope-left-npm-1.2.0-",
+		"left@patch:left@npm%3A1.2.0#./fix.patch": "left-patch-",
+	} {
+		actual, _ := slugifyLocator(locator)
+		if !strings.HasPrefix(actual, expected) {
+			t.Errorf("expected %s to slugify to %s, got %s", locator, expected, actual)
+		}
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	folder := t.TempDir()
+	write := func(locator string, suffix string, content string) string {
+		slug, _ := slugifyLocator(locator)
+		if err := ioutil.WriteFile(filepath.Join(folder, slug+"-"+suffix+".zip"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		checksum, err := ArchiveChecksum(filepath.Join(folder, slug+"-"+suffix+".zip"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return checksum
+	}
+
+	good := write("good@npm:1.0.0", "8", "good")
+	tampered := write("tampered@npm:1.0.0", "8", "tampered")
+	write("tampered@npm:1.0.0", "8", "tampered again")
+
+	lock := newLock(BerryLockfile)
+	lock.resolutions = map[string]Resolution{
+		"__metadata":                {Version: "6", CacheKey: "8"},
+		"app@workspace:.":           {Resolution: "app@workspace:."},
+		"good@npm:^1.0.0":           {Resolution: "good@npm:1.0.0", Checksum: good},
+		"tampered@npm:^1.0.0":       {Resolution: "tampered@npm:1.0.0", Checksum: tampered},
+		"missing@npm:^1.0.0":        {Resolution: "missing@npm:1.0.0", Checksum: "10c0/abc"},
+		"unchecked@https://x/u.tgz": {Resolution: "unchecked@https://x/u.tgz"},
+	}
+
+	problems, verified, err := (&Cache{Folder: folder}).VerifyChecksums(lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []string
+	for _, problem := range problems {
+		actual = append(actual, problem.Locator+" "+problem.Problem)
+	}
+
+	expected := []string{"missing@npm:1.0.0 missing", "tampered@npm:1.0.0 mismatch", "unchecked@https://x/u.tgz no checksum"}
+	if verified != 1 || !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected 1 verified and %v, got %d and %v", expected, verified, actual)
+	}
+}
+
+func TestNewCache(t *testing.T) {
+	disabled := false
+	config := &Config{Directory: "/project", EnableGlobalCache: &disabled}
+	if cache := NewCache(config, nil); cache.Global || cache.Folder != filepath.Join("/project", ".yarn", "cache") {
+		t.Errorf("expected the project cache, got %v", cache)
+	}
+
+	config = &Config{Directory: "/project", GlobalFolder: "/global"}
+	if cache := NewCache(config, nil); !cache.Global || cache.Folder != filepath.Join("/global", "cache") {
+		t.Errorf("expected the global cache, got %v", cache)
+	}
+}
+
+func TestCachePruneAndMissing(t *testing.T) {
+	folder := t.TempDir()
+	archive := func(locator string, suffix string) string {
+		slug, _ := slugifyLocator(locator)
+		name := slug + "-" + suffix + ".zip"
+		if err := ioutil.WriteFile(filepath.Join(folder, name), []byte(locator), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		return name
+	}
+
+	archive("lodash@npm:4.17.21", "abcdef0123")
+	stale := archive("lodash@npm:4.17.20", "abcdef0123")
+	archive("left@patch:left@npm%3A1.2.0#./fix.patch::version=1.2.0&hash=abc", "10c0")
+	archive("left@patch:left@npm%3A1.2.0#./fix.patch::version=1.2.0&hash=def", "10c0")
+
+	lock := newLock(BerryLockfile)
+	lock.resolutions = map[string]Resolution{
+		"__metadata":           {Version: "8", CacheKey: "10c0"},
+		"app@workspace:.":      {Resolution: "app@workspace:."},
+		"lodash@npm:^4.17.0":   {Resolution: "lodash@npm:4.17.21", Checksum: "10c0/abcdef0123456789"},
+		"left@patch:left@...":  {Resolution: "left@patch:left@npm%3A1.2.0#./fix.patch::version=1.2.0&hash=abc", Checksum: "abc"},
+		"right@npm:^1.0.0":     {Resolution: "right@npm:1.0.0", Checksum: "10c0/0123456789abcdef"},
+		"tool@link:./packages": {Resolution: "tool@link:./packages"},
+	}
+
+	cache := &Cache{Folder: folder}
+	unreferenced, err := cache.Unreferenced(lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unreferenced) != 1 || filepath.Base(unreferenced[0]) != stale {
+		t.Errorf("expected only %s to be unreferenced, got %v", stale, unreferenced)
+	}
+
+	if missing := cache.Missing(lock); !reflect.DeepEqual(missing, []string{"right@npm:1.0.0"}) {
+		t.Errorf("expected right to be missing, got %v", missing)
+	}
+}
@@ -0,0 +1,333 @@
+package yarn
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const classicHeader = "# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.\n# yarn lockfile v1\n"
+
+// isClassicLock tells yarn 1 lockfiles from berry ones, which are YAML with __metadata.
+func isClassicLock(data []byte) bool {
+	if bytes.Contains(data, []byte("# yarn lockfile v1")) {
+		return true
+	}
+
+	return !bytes.Contains(data, []byte("__metadata:")) && classicField.Match(data)
+}
+
+var classicField = regexp.MustCompile(`(?m)^  version "`)
+
+// parseClassicLock reads a yarn 1 lockfile into the berry model: descriptors and locators get the npm: protocol,
+// optionalDependencies become optional dependencies and resolved and integrity are kept as they are.
+func parseClassicLock(data []byte) (*Lock, error) {
+	lock := newLock(ClassicLockfile)
+
+	var key string
+	var entry *Resolution
+	var section string
+	flush := func() {
+		if entry == nil {
+			return
+		}
+
+		name, rng := splitLocator(strings.Split(key, ", ")[0])
+		switch {
+		case !strings.HasPrefix(rng, "npm:"):
+			entry.Resolution = name + "@" + rng
+		default:
+			if alias, aliasRange := splitLocator(strings.TrimPrefix(rng, "npm:")); aliasRange != "" {
+				name = alias
+			}
+
+			entry.Resolution = fmt.Sprintf("%s@npm:%s", name, entry.Version)
+		}
+
+		lock.resolutions[key] = *entry
+		entry = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for number := 1; scanner.Scan(); number++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		switch {
+		case indent == 0:
+			flush()
+			if !strings.HasSuffix(trimmed, ":") {
+				return nil, fmt.Errorf("cannot deserialize yarn.lock: line %d: expected descriptors", number)
+			}
+
+			descriptors, err := parseClassicKeys(strings.TrimSuffix(trimmed, ":"))
+			if err != nil {
+				return nil, fmt.Errorf("cannot deserialize yarn.lock: line %d: %v", number, err)
+			}
+
+			key, entry, section = classicKey(descriptors), &Resolution{}, ""
+		case entry == nil:
+			return nil, fmt.Errorf("cannot deserialize yarn.lock: line %d: field outside of an entry", number)
+		case indent == 2 && strings.HasSuffix(trimmed, ":"):
+			section = strings.TrimSuffix(trimmed, ":")
+		case indent == 2:
+			section = ""
+			field, value, err := parseClassicPair(trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("cannot deserialize yarn.lock: line %d: %v", number, err)
+			}
+
+			switch field {
+			case "version":
+				entry.Version = value
+			case "resolved":
+				entry.Resolved = value
+			case "integrity":
+				entry.Integrity = value
+			}
+		case indent == 4 && section != "":
+			name, value, err := parseClassicPair(trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("cannot deserialize yarn.lock: line %d: %v", number, err)
+			}
+
+			entry.addClassicDependency(section, name, value)
+		default:
+			return nil, fmt.Errorf("cannot deserialize yarn.lock: line %d: unexpected indentation", number)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read yarn.lock: %v", err)
+	}
+
+	flush()
+
+	if len(lock.resolutions) == 0 {
+		return nil, fmt.Errorf("no entries found in yarn.lock")
+	}
+
+	return lock, nil
+}
+
+func (entry *Resolution) addClassicDependency(section string, name string, value string) {
+	switch section {
+	case "dependencies", "optionalDependencies":
+		if entry.Dependencies == nil {
+			entry.Dependencies = make(map[string]string)
+		}
+
+		entry.Dependencies[name] = value
+		if section == "optionalDependencies" {
+			if entry.DependenciesMeta == nil {
+				entry.DependenciesMeta = make(map[string]DependencyMeta)
+			}
+
+			entry.DependenciesMeta[name] = DependencyMeta{Optional: true}
+		}
+	case "peerDependencies":
+		if entry.PeerDependencies == nil {
+			entry.PeerDependencies = make(map[string]string)
+		}
+
+		entry.PeerDependencies[name] = value
+	}
+}
+
+// addWorkspace adds the entry berry has for the project itself, depending on its direct dependencies.
+func (lock *Lock) addWorkspace(project *Package) {
+	name := project.Name
+	if name == "" {
+		name = "root-workspace"
+	}
+
+	locator := name + "@workspace:."
+	workspace := Resolution{Version: "0.0.0-use.local", Resolution: locator, LanguageName: "unknown", LinkType: "soft"}
+	for _, dependencies := range []map[string]string{project.Dependencies, project.DevDependencies} {
+		for dependency, request := range dependencies {
+			workspace.addClassicDependency("dependencies", dependency, request)
+		}
+	}
+
+	for dependency, request := range project.OptionalDependencies {
+		workspace.addClassicDependency("optionalDependencies", dependency, request)
+	}
+
+	lock.resolutions[locator] = workspace
+}
+
+// classicKey turns yarn 1 descriptors into a berry key, adding the npm: protocol to plain ranges.
+func classicKey(descriptors []string) string {
+	var keys []string
+	for _, descriptor := range descriptors {
+		name, rng := splitLocator(descriptor)
+		if !strings.Contains(rng, ":") {
+			rng = "npm:" + rng
+		}
+
+		keys = append(keys, name+"@"+rng)
+	}
+
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+func parseClassicKeys(keys string) ([]string, error) {
+	var descriptors []string
+	for _, key := range strings.Split(keys, ",") {
+		descriptor, err := unquoteClassic(strings.TrimSpace(key))
+		if err != nil {
+			return nil, err
+		}
+
+		descriptors = append(descriptors, descriptor)
+	}
+
+	return descriptors, nil
+}
+
+// parseClassicPair splits `name "value"` lines, where both may be quoted.
+func parseClassicPair(line string) (string, string, error) {
+	var name, rest string
+	if strings.HasPrefix(line, `"`) {
+		end := strings.Index(line[1:], `"`)
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated string %s", line)
+		}
+
+		name, rest = line[1:end+1], strings.TrimSpace(line[end+2:])
+	} else if loc := strings.Index(line, " "); loc >= 0 {
+		name, rest = line[:loc], strings.TrimSpace(line[loc+1:])
+	} else {
+		return "", "", fmt.Errorf("expected a value in %s", line)
+	}
+
+	value, err := unquoteClassic(rest)
+	return name, value, err
+}
+
+func unquoteClassic(value string) (string, error) {
+	if !strings.HasPrefix(value, `"`) {
+		return value, nil
+	}
+
+	return strconv.Unquote(value)
+}
+
+// serializeClassicLock writes the berry model back in the yarn 1 format, leaving out workspaces.
+func serializeClassicLock(lock *Lock) []byte {
+	type block struct {
+		descriptors []string
+		entry       Resolution
+	}
+
+	var blocks []block
+	for key, resolution := range lock.resolutions {
+		if _, reference := splitLocator(resolution.Resolution); strings.HasPrefix(reference, "workspace:") || key == "__metadata" {
+			continue
+		}
+
+		var descriptors []string
+		for _, descriptor := range strings.Split(key, ", ") {
+			name, rng := splitLocator(descriptor)
+			descriptors = append(descriptors, name+"@"+classicRange(rng))
+		}
+
+		sort.Strings(descriptors)
+		blocks = append(blocks, block{descriptors: descriptors, entry: resolution})
+	}
+
+	// yarn sorts the descriptors before quoting them, which moves quoted ones like scoped packages and aliases.
+	sort.Slice(blocks, func(p, q int) bool { return blocks[p].descriptors[0] < blocks[q].descriptors[0] })
+
+	var b bytes.Buffer
+	b.WriteString(classicHeader + "\n")
+	for _, block := range blocks {
+		var keys []string
+		for _, descriptor := range block.descriptors {
+			keys = append(keys, quoteClassic(descriptor))
+		}
+
+		fmt.Fprintf(&b, "\n%s:\n", strings.Join(keys, ", "))
+		fmt.Fprintf(&b, "  version %s\n", quoteClassic(block.entry.Version))
+		if block.entry.Resolved != "" {
+			fmt.Fprintf(&b, "  resolved %s\n", quoteClassic(block.entry.Resolved))
+		}
+
+		if block.entry.Integrity != "" {
+			fmt.Fprintf(&b, "  integrity %s\n", quoteClassic(block.entry.Integrity))
+		}
+
+		dependencies, optional := make(map[string]string), make(map[string]string)
+		for name, request := range block.entry.Dependencies {
+			if block.entry.DependenciesMeta[name].Optional {
+				optional[name] = classicRange(request)
+			} else {
+				dependencies[name] = classicRange(request)
+			}
+		}
+
+		writeClassicSection(&b, "dependencies", dependencies)
+		writeClassicSection(&b, "optionalDependencies", optional)
+		writeClassicSection(&b, "peerDependencies", block.entry.PeerDependencies)
+	}
+
+	return b.Bytes()
+}
+
+func writeClassicSection(b *bytes.Buffer, section string, dependencies map[string]string) {
+	if len(dependencies) == 0 {
+		return
+	}
+
+	var names []string
+	for name := range dependencies {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Fprintf(b, "  %s:\n", section)
+	for _, name := range names {
+		fmt.Fprintf(b, "    %s %s\n", quoteClassic(name), quoteClassic(dependencies[name]))
+	}
+}
+
+// classicRange drops the npm: protocol yarn 1 does not know.
+func classicRange(rng string) string {
+	if trimmed := strings.TrimPrefix(rng, "npm:"); !strings.Contains(trimmed, "@") {
+		return trimmed
+	}
+
+	return rng
+}
+
+var classicPlain = regexp.MustCompile(`^[a-zA-Z][^:\s\n\\",\[\]]*$`)
+
+// quoteClassic quotes like yarn 1 does: strings starting with anything but a letter, containing special
+// characters or looking like booleans.
+func quoteClassic(value string) string {
+	if classicPlain.MatchString(value) && !strings.HasPrefix(value, "true") && !strings.HasPrefix(value, "false") {
+		return value
+	}
+
+	return strconv.Quote(value)
+}
+
+// classic returns the resolved url and integrity yarn 1 locks a tarball with.
+func (dist Dist) classic() (string, string) {
+	if dist.Shasum == "" {
+		return dist.Tarball, dist.Integrity
+	}
+
+	return dist.Tarball + "#" + dist.Shasum, dist.Integrity
+}
@@ -0,0 +1,136 @@
+package yarn_test
+
+import (
+	"gnarl/yarn"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const classicLock = `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@scope/left@^1.0.0":
+  version "1.2.0"
+  resolved "https://registry.yarnpkg.com/@scope/left/-/left-1.2.0.tgz#0123abcd"
+  integrity sha512-left==
+  dependencies:
+    lodash "^4.17.0"
+  optionalDependencies:
+    fsevents "^2.0.0"
+
+fsevents@^2.0.0:
+  version "2.3.2"
+  resolved "https://registry.yarnpkg.com/fsevents/-/fsevents-2.3.2.tgz#8a526f78"
+  integrity sha512-fsevents==
+
+lodash@^4.17.0, lodash@^4.17.20:
+  version "4.17.20"
+  resolved "https://registry.yarnpkg.com/lodash/-/lodash-4.17.20.tgz#b44a9b6297bcb698f1c51a3545a2b3b368d59c52"
+  integrity sha512-lodash==
+`
+
+func TestClassicRoundTrip(t *testing.T) {
+	lock := mustParseLock(t, classicLock)
+	if lock.Format() != yarn.ClassicLockfile {
+		t.Fatal("expected a classic lockfile")
+	}
+
+	if actual := lockedLocators(lock); actual != "@scope/left@1.2.0 fsevents@2.3.2 lodash@4.17.20" {
+		t.Errorf("unexpected packages %s", actual)
+	}
+
+	if actual := lock.Dependents("lodash@npm:^4.17.0, lodash@npm:^4.17.20"); !reflect.DeepEqual(actual, []string{"@scope/left@npm:1.2.0"}) {
+		t.Errorf("unexpected dependents of lodash %v", actual)
+	}
+
+	file := filepath.Join(t.TempDir(), "yarn.lock")
+	if err := lock.WriteFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(written) != classicLock {
+		t.Errorf("expected\n%s\ngot\n%s", classicLock, written)
+	}
+}
+
+func TestClassicWhy(t *testing.T) {
+	directory := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(directory, "yarn.lock"), []byte(classicLock), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(directory, "package.json"), []byte(`{"name": "app", "dependencies": {"@scope/left": "^1.0.0", "lodash": "^4.17.20"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := yarn.ReadLock(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pattern, err := yarn.ParseResetPattern("lodash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chains, truncated := lock.Why(pattern, 10)
+	var actual []string
+	for _, chain := range chains {
+		actual = append(actual, strings.Join(chain, " > "))
+	}
+
+	expected := []string{"app@workspace:. > lodash@npm:4.17.20", "app@workspace:. > @scope/left@npm:1.2.0 > lodash@npm:4.17.20"}
+	if truncated || !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v (truncated %v)", expected, actual, truncated)
+	}
+
+	if chains, truncated = lock.Why(pattern, 1); len(chains) != 1 || !truncated {
+		t.Errorf("expected one chain and truncation, got %v (truncated %v)", chains, truncated)
+	}
+}
+
+// classicQuotedLock is in the order yarn writes, which sorts the descriptors before quoting them.
+const classicQuotedLock = `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@babel/core@^7.0.0":
+  version "7.24.0"
+  resolved "https://registry.yarnpkg.com/@babel/core/-/core-7.24.0.tgz#56cbda6b"
+  integrity sha512-core==
+
+abbrev@^1.0.0:
+  version "1.1.1"
+  resolved "https://registry.yarnpkg.com/abbrev/-/abbrev-1.1.1.tgz#f8f2c887"
+  integrity sha512-abbrev==
+
+"string-width-cjs@npm:string-width@^4.2.0", string-width@^4.2.0:
+  version "4.2.3"
+  resolved "https://registry.yarnpkg.com/string-width/-/string-width-4.2.3.tgz#269c7117"
+  integrity sha512-width==
+
+"strip-ansi-cjs@npm:strip-ansi@^6.0.1":
+  version "6.0.1"
+  resolved "https://registry.yarnpkg.com/strip-ansi/-/strip-ansi-6.0.1.tgz#9e26c630"
+  integrity sha512-ansi==
+
+wrap-ansi@^7.0.0:
+  version "7.0.0"
+  resolved "https://registry.yarnpkg.com/wrap-ansi/-/wrap-ansi-7.0.0.tgz#67e145cf"
+  integrity sha512-wrap==
+`
+
+func TestClassicSortsUnquotedDescriptors(t *testing.T) {
+	if written := mustSerialize(t, mustParseLock(t, classicQuotedLock)); written != classicQuotedLock {
+		t.Errorf("expected\n%s\ngot\n%s", classicQuotedLock, written)
+	}
+}
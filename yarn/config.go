@@ -0,0 +1,199 @@
+package yarn
+
+import (
+	"fmt"
+	"gnarl/semver"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	yaml2 "gopkg.in/yaml.v2"
+)
+
+type Config struct {
+	YarnPath                 string                 `yaml:"yarnPath,omitempty"`
+	NodeLinker               string                 `yaml:"nodeLinker,omitempty"`
+	CacheFolder              string                 `yaml:"cacheFolder,omitempty"`
+	GlobalFolder             string                 `yaml:"globalFolder,omitempty"`
+	EnableGlobalCache        *bool                  `yaml:"enableGlobalCache,omitempty"`
+	ChecksumBehavior         string                 `yaml:"checksumBehavior,omitempty"`
+	NpmRegistryServer        string                 `yaml:"npmRegistryServer,omitempty"`
+	NpmAuthToken             string                 `yaml:"npmAuthToken,omitempty"`
+	NpmAuthIdent             string                 `yaml:"npmAuthIdent,omitempty"`
+	NpmAlwaysAuth            bool                   `yaml:"npmAlwaysAuth,omitempty"`
+	NpmScopes                map[string]ScopeConfig `yaml:"npmScopes,omitempty"`
+	NpmAuditRegistry         string                 `yaml:"npmAuditRegistry,omitempty"`
+	NpmAuditExcludePackages  []string               `yaml:"npmAuditExcludePackages,omitempty"`
+	NpmAuditIgnoreAdvisories []string               `yaml:"npmAuditIgnoreAdvisories,omitempty"`
+
+	// Directory holds the .yarnrc.yml the relative paths are resolved against.
+	Directory string `yaml:"-"`
+}
+
+type ScopeConfig struct {
+	NpmRegistryServer  string `yaml:"npmRegistryServer,omitempty"`
+	NpmPublishRegistry string `yaml:"npmPublishRegistry,omitempty"`
+	NpmAuthToken       string `yaml:"npmAuthToken,omitempty"`
+	NpmAuthIdent       string `yaml:"npmAuthIdent,omitempty"`
+	NpmAlwaysAuth      bool   `yaml:"npmAlwaysAuth,omitempty"`
+}
+
+func yarnrc(directory string) string {
+	return fmt.Sprintf("%s/.yarnrc.yml", directory)
+}
+
+// ReadConfig reads the .yarnrc.yml in the home directory and then the closest one of the project, like yarn does.
+func ReadConfig(directory string) (*Config, error) {
+	config := Config{Directory: directory}
+
+	var paths []string
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, yarnrc(home))
+	}
+
+	if project := findUp(directory, ".yarnrc.yml"); project != "" {
+		config.Directory = project
+		if len(paths) == 0 || filepath.Clean(paths[0]) != filepath.Clean(yarnrc(project)) {
+			paths = append(paths, yarnrc(project))
+		}
+	}
+
+	for _, path := range paths {
+		yaml, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("cannot read .yarnrc.yml: %v", err)
+		}
+
+		err = yaml2.Unmarshal(yaml, &config)
+		if err != nil {
+			return nil, fmt.Errorf("cannot deserialize %s: %v", path, err)
+		}
+	}
+
+	if err := config.interpolate(os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("cannot read .yarnrc.yml: %v", err)
+	}
+
+	return &config, nil
+}
+
+// findUp returns the closest directory, starting at the given one, that holds the named file.
+func findUp(directory string, name string) string {
+	current, err := filepath.Abs(directory)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(current, name)); err == nil {
+			return current
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return ""
+		}
+
+		current = parent
+	}
+}
+
+var environmentVariable = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)(:?-([^}]*))?\}`)
+
+// interpolate replaces ${NAME}, ${NAME-default} and ${NAME:-default} in the settings yarn interpolates.
+func (config *Config) interpolate(lookup func(string) (string, bool)) error {
+	fields := []*string{
+		&config.YarnPath, &config.CacheFolder, &config.GlobalFolder,
+		&config.NpmRegistryServer, &config.NpmAuthToken, &config.NpmAuthIdent, &config.NpmAuditRegistry,
+	}
+
+	for scope, settings := range config.NpmScopes {
+		for _, field := range []*string{&settings.NpmRegistryServer, &settings.NpmPublishRegistry, &settings.NpmAuthToken, &settings.NpmAuthIdent} {
+			value, err := interpolate(*field, lookup)
+			if err != nil {
+				return fmt.Errorf("npmScopes.%s: %v", scope, err)
+			}
+
+			*field = value
+		}
+
+		config.NpmScopes[scope] = settings
+	}
+
+	for _, field := range fields {
+		value, err := interpolate(*field, lookup)
+		if err != nil {
+			return err
+		}
+
+		*field = value
+	}
+
+	return nil
+}
+
+func interpolate(value string, lookup func(string) (string, bool)) (string, error) {
+	var missing string
+	result := environmentVariable.ReplaceAllStringFunc(value, func(match string) string {
+		groups := environmentVariable.FindStringSubmatch(match)
+		name, operator, fallback := groups[1], groups[2], groups[3]
+
+		variable, ok := lookup(name)
+		switch {
+		case ok && (variable != "" || !strings.HasPrefix(operator, ":")):
+			return variable
+		case operator != "":
+			return fallback
+		default:
+			missing = name
+			return match
+		}
+	})
+
+	if missing != "" {
+		return "", fmt.Errorf("environment variable %s is not set", missing)
+	}
+
+	return result, nil
+}
+
+// Path resolves a path from .yarnrc.yml, which is relative to the directory of that file.
+func (config *Config) Path(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(config.Directory, path)
+}
+
+var yarnRelease = regexp.MustCompile(`^yarn-(\d+\.\d+\.\d+[^/]*?)\.c?js$`)
+
+// DetectVersion finds the yarn version of a project from packageManager in package.json or yarnPath in .yarnrc.yml,
+// so that no yarn process is needed.
+func DetectVersion(directory string, config *Config) (*semver.Version, error) {
+	if root := findUp(directory, "package.json"); root != "" {
+		for current := root; ; current = filepath.Dir(current) {
+			if project, err := ReadPackage(current); err == nil && project.PackageManager != "" {
+				return project.YarnVersion()
+			}
+
+			if filepath.Dir(current) == current {
+				break
+			}
+		}
+	}
+
+	if config != nil && config.YarnPath != "" {
+		if match := yarnRelease.FindStringSubmatch(filepath.Base(config.YarnPath)); match != nil {
+			return semver.ParseVersion(match[1])
+		}
+	}
+
+	return nil, fmt.Errorf("no packageManager in package.json and no yarn release in yarnPath")
+}
@@ -0,0 +1,82 @@
+package yarn
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	environment := map[string]string{"TOKEN": "secret", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		value, ok := environment[name]
+		return value, ok
+	}
+
+	for value, expected := range map[string]string{
+		"${TOKEN}":               "secret",
+		"Bearer ${TOKEN}":        "Bearer secret",
+		"${MISSING:-fallback}":   "fallback",
+		"${EMPTY:-fallback}":     "fallback",
+		"${EMPTY-fallback}":      "",
+		"https://${MISSING-npm}": "https://npm",
+	} {
+		if actual, err := interpolate(value, lookup); err != nil || actual != expected {
+			t.Errorf("Expected %s to interpolate to %q, got %q (%v)", value, expected, actual, err)
+		}
+	}
+
+	if _, err := interpolate("${MISSING}", lookup); err == nil {
+		t.Error("Expected an error for a missing variable")
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	directory := t.TempDir()
+	workspace := filepath.Join(directory, "packages", "app")
+	rc := `yarnPath: .yarn/releases/yarn-3.6.4.cjs
+nodeLinker: node-modules
+enableGlobalCache: false
+npmScopes:
+  internal:
+    npmRegistryServer: https://npm.example.com
+    npmAuthToken: ${GNARL_TEST_UNSET_TOKEN:-none}
+npmAuditIgnoreAdvisories:
+  - GHSA-*
+`
+	if err := ioutil.WriteFile(yarnrc(directory), []byte(rc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(workspace, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := ReadConfig(workspace)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.NodeLinker != "node-modules" || *config.EnableGlobalCache || config.NpmScopes["internal"].NpmAuthToken != "none" {
+		t.Errorf("Unexpected config %+v", config)
+	}
+
+	if config.Path(config.YarnPath) != filepath.Join(directory, ".yarn/releases/yarn-3.6.4.cjs") {
+		t.Errorf("Expected yarnPath relative to .yarnrc.yml, got %s", config.Path(config.YarnPath))
+	}
+
+	version, err := DetectVersion(workspace, config)
+	if err != nil || version.String() != "3.6.4" {
+		t.Errorf("Expected yarn 3.6.4 from yarnPath, got %v (%v)", version, err)
+	}
+
+	if err := ioutil.WriteFile(packageJson(directory), []byte(`{"packageManager": "yarn@4.1.0+sha224.953c8233"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	version, err = DetectVersion(workspace, config)
+	if err != nil || version.String() != "4.1.0" {
+		t.Errorf("Expected yarn 4.1.0 from packageManager, got %v (%v)", version, err)
+	}
+}
@@ -0,0 +1,240 @@
+package yarn
+
+import (
+	"gnarl/semver"
+	"log"
+	"sort"
+	"strings"
+)
+
+// Convert copies the lock into another lockfile format, keeping every locked version, so that the target manager
+// installs without resolving again. Metadata, when given, fills in what the source format does not record: the
+// tarballs and integrity hashes berry leaves out and the ranges pnpm replaces with the versions they resolved to.
+// Patched packages have no equivalent outside berry and are left for the target manager to resolve.
+func (lock *Lock) Convert(target Lockfile, metadata Metadata) *Lock {
+	packuments := packumentCache{metadata: metadata, packuments: make(map[string]*Packument)}
+
+	entries := lock.descriptorEntries()
+	if lock.Format().Manager() == Pnpm && metadata != nil {
+		entries = restoreRanges(entries, &packuments)
+	}
+
+	converted := newLock(target)
+	converted.dirty = true
+	for descriptor, resolution := range entries {
+		if descriptor == "__metadata" {
+			delete(entries, descriptor)
+			continue
+		}
+
+		_, reference := splitLocator(resolution.Resolution)
+		if target.Manager() != Berry && strings.HasPrefix(reference, "patch:") {
+			log.Printf("Drop %s: patches cannot be converted to %s", descriptor, target.Manager())
+			delete(entries, descriptor)
+			continue
+		}
+
+		if target.Manager() == Berry {
+			entries[descriptor] = berryResolution(resolution)
+		} else {
+			entries[descriptor] = packuments.tarball(plainResolution(resolution, target.Manager()), target.Manager())
+		}
+	}
+
+	converted.group(entries)
+	if target.Manager() == Berry {
+		converted.resolutions["__metadata"] = Resolution{Version: "8", CacheKey: "10c0"}
+	}
+
+	return converted
+}
+
+// berryResolution adds what berry records for every package and drops the tarball, berry checksums its own archives.
+func berryResolution(resolution Resolution) Resolution {
+	resolution.LanguageName, resolution.LinkType = "node", "hard"
+	if _, reference := splitLocator(resolution.Resolution); strings.HasPrefix(reference, "workspace:") {
+		resolution.LanguageName, resolution.LinkType = "unknown", "soft"
+	}
+
+	resolution.Dependencies = mapRequests(resolution.Dependencies, func(request string) string {
+		if strings.Contains(request, ":") {
+			return request
+		}
+
+		return "npm:" + request
+	})
+
+	resolution.Resolved, resolution.Integrity = "", ""
+	return resolution
+}
+
+// plainResolution drops the berry specifics, checksums of berry archives mean nothing to other managers, and the
+// shasum yarn classic appends to tarballs.
+func plainResolution(resolution Resolution, manager Manager) Resolution {
+	resolution.Checksum, resolution.CacheKey, resolution.LanguageName, resolution.LinkType = "", "", "", ""
+	if _, reference := splitLocator(resolution.Resolution); manager != Classic && strings.HasPrefix(reference, "npm:") {
+		resolution.Resolved = strings.SplitN(resolution.Resolved, "#", 2)[0]
+	}
+
+	resolution.Dependencies = mapRequests(resolution.Dependencies, classicRange)
+	return resolution
+}
+
+func mapRequests(dependencies map[string]string, convert func(string) string) map[string]string {
+	if len(dependencies) == 0 {
+		return dependencies
+	}
+
+	converted := make(map[string]string)
+	for name, request := range dependencies {
+		converted[name] = convert(request)
+	}
+
+	return converted
+}
+
+// restoreRanges replaces the versions pnpm records for the dependencies of packages with the ranges of their
+// manifests, when the locked version satisfies them and no other entry is locked for the range. Descriptors nothing
+// refers to anymore are dropped.
+func restoreRanges(entries map[string]Resolution, packuments *packumentCache) map[string]Resolution {
+	locked := make(map[string]Resolution)
+	owners := make(map[string]string)
+	for descriptor, resolution := range entries {
+		locked[resolution.Resolution] = resolution
+		owners[descriptor] = resolution.Resolution
+	}
+
+	var locators []string
+	for locator := range locked {
+		locators = append(locators, locator)
+	}
+
+	sort.Strings(locators)
+
+	for _, locator := range locators {
+		name, reference := splitLocator(locator)
+		resolution := locked[locator]
+		if !strings.HasPrefix(reference, "npm:") || len(resolution.Dependencies) == 0 {
+			continue
+		}
+
+		manifest, ok := packuments.manifest(name, resolution.Version)
+		if !ok {
+			continue
+		}
+
+		dependencies := make(map[string]string)
+		for dependency, request := range resolution.Dependencies {
+			dependencies[dependency] = request
+
+			rng := manifest.Dependencies[dependency]
+			if optional, ok := manifest.OptionalDependencies[dependency]; ok {
+				rng = optional
+			}
+
+			current, ok := owners[npmDescriptor(dependency, request)]
+			if !ok || rng == "" || rng == request {
+				continue
+			}
+
+			version, err := semver.ParseVersion(locked[current].Version)
+			if err != nil {
+				continue
+			}
+
+			if request, err := semver.ParseRequest(rng); err != nil || !request.Matches(version) {
+				continue
+			}
+
+			descriptor := npmDescriptor(dependency, rng)
+			if owner, ok := owners[descriptor]; ok && owner != current {
+				continue
+			}
+
+			owners[descriptor] = current
+			dependencies[dependency] = rng
+		}
+
+		resolution.Dependencies = dependencies
+		locked[locator] = resolution
+	}
+
+	referenced := make(map[string]bool)
+	for descriptor, locator := range owners {
+		if _, reference := splitLocator(locator); strings.HasPrefix(reference, "workspace:") {
+			referenced[descriptor] = true
+		}
+	}
+
+	for _, resolution := range locked {
+		for dependency, request := range resolution.Dependencies {
+			referenced[npmDescriptor(dependency, request)] = true
+		}
+	}
+
+	result := make(map[string]Resolution)
+	for descriptor, locator := range owners {
+		if referenced[descriptor] {
+			result[descriptor] = locked[locator]
+		}
+	}
+
+	return result
+}
+
+// packumentCache asks the metadata for each package once, logging failures instead of failing the conversion.
+type packumentCache struct {
+	metadata   Metadata
+	packuments map[string]*Packument
+}
+
+func (cache *packumentCache) manifest(name string, version string) (Manifest, bool) {
+	if cache.metadata == nil {
+		return Manifest{}, false
+	}
+
+	packument, ok := cache.packuments[name]
+	if !ok {
+		var err error
+		if packument, err = cache.metadata.Packument(name); err != nil {
+			log.Printf("cannot complete %s: %v", name, err)
+		}
+
+		cache.packuments[name] = packument
+	}
+
+	if packument == nil {
+		return Manifest{}, false
+	}
+
+	manifest, ok := packument.Versions[version]
+	return manifest, ok
+}
+
+// tarball fills in the tarball and integrity of registry packages that have none, yarn classic wants the shasum too.
+func (cache *packumentCache) tarball(resolution Resolution, manager Manager) Resolution {
+	name, reference := splitLocator(resolution.Resolution)
+	if !strings.HasPrefix(reference, "npm:") || resolution.Resolved != "" && resolution.Integrity != "" {
+		return resolution
+	}
+
+	manifest, ok := cache.manifest(name, resolution.Version)
+	if !ok {
+		return resolution
+	}
+
+	resolved, integrity := manifest.Dist.Tarball, manifest.Dist.Integrity
+	if manager == Classic {
+		resolved, integrity = manifest.Dist.classic()
+	}
+
+	if resolution.Resolved == "" {
+		resolution.Resolved = resolved
+	}
+
+	if resolution.Integrity == "" {
+		resolution.Integrity = integrity
+	}
+
+	return resolution
+}
@@ -0,0 +1,93 @@
+package yarn_test
+
+import (
+	"gnarl/yarn"
+	"strings"
+	"testing"
+)
+
+func mustSerialize(t *testing.T, lock *yarn.Lock) string {
+	data, err := lock.Format().Serialize(lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestConvertClassicToBerry(t *testing.T) {
+	converted := mustParseLock(t, classicLock).Convert(yarn.BerryLockfile, nil)
+	written := mustSerialize(t, converted)
+
+	for _, expected := range []string{"__metadata:\n  version: \"8\"", "lodash: npm:^4.17.0", "linkType: hard"} {
+		if !strings.Contains(written, expected) {
+			t.Errorf("expected %q in\n%s", expected, written)
+		}
+	}
+
+	if strings.Contains(written, "integrity") {
+		t.Errorf("expected no integrity in\n%s", written)
+	}
+
+	reread := mustParseLock(t, written)
+	if reread.Manager() != yarn.Berry || lockedLocators(reread) != "@scope/left@1.2.0 fsevents@2.3.2 lodash@4.17.20" {
+		t.Errorf("unexpected %s packages %s", reread.Manager(), lockedLocators(reread))
+	}
+}
+
+func TestConvertBerryToNpm(t *testing.T) {
+	metadata := fakeMetadata{
+		"lodash": {Versions: map[string]yarn.Manifest{
+			"4.17.20": {Version: "4.17.20", Dist: yarn.Dist{Tarball: "https://registry.yarnpkg.com/lodash/-/lodash-4.17.20.tgz", Integrity: "sha512-lodash==", Shasum: "b44a"}},
+		}},
+	}
+
+	written := mustSerialize(t, mustParseLock(t, testLock).Convert(yarn.NpmLockfile, metadata))
+	for _, expected := range []string{`"lockfileVersion": 3`, `"resolved": "https://registry.yarnpkg.com/lodash/-/lodash-4.17.20.tgz",`, `"integrity": "sha512-lodash=="`} {
+		if !strings.Contains(written, expected) {
+			t.Errorf("expected %q in\n%s", expected, written)
+		}
+	}
+
+	reread := mustParseLock(t, written)
+	if reread.Manager() != yarn.Npm || lockedLocators(reread) != "@scope/left@1.2.0 lodash@4.17.20" {
+		t.Errorf("unexpected %s packages %s", reread.Manager(), lockedLocators(reread))
+	}
+}
+
+func TestConvertNpmNesting(t *testing.T) {
+	berry := mustParseLock(t, mustSerialize(t, mustParseLock(t, npmLock).Convert(yarn.BerryLockfile, nil)))
+	written := mustSerialize(t, berry.Convert(yarn.NpmLockfile, nil))
+
+	for _, expected := range []string{`"node_modules/left/node_modules/lodash": {`, `"node_modules/tool": {`, `"workspaces": [`} {
+		if !strings.Contains(written, expected) {
+			t.Errorf("expected %q in\n%s", expected, written)
+		}
+	}
+
+	if actual := lockedLocators(mustParseLock(t, written)); actual != lockedLocators(mustParseLock(t, npmLock)) {
+		t.Errorf("expected the packages of the original, got %s", actual)
+	}
+}
+
+func TestConvertPnpmRestoresRanges(t *testing.T) {
+	metadata := fakeMetadata{
+		"left": {Versions: map[string]yarn.Manifest{
+			"1.2.0": {Version: "1.2.0", Dependencies: map[string]string{"lodash": "^3.0.0"}, PeerDependencies: map[string]string{"react": ">=17"}},
+		}},
+		"lodash": {Versions: map[string]yarn.Manifest{
+			"3.10.1": {Version: "3.10.1", Dist: yarn.Dist{Tarball: "https://registry.yarnpkg.com/lodash/-/lodash-3.10.1.tgz", Shasum: "5bf4"}},
+		}},
+	}
+
+	written := mustSerialize(t, mustParseLock(t, pnpmLock).Convert(yarn.ClassicLockfile, metadata))
+	for _, expected := range []string{"lodash@^3.0.0:\n", "    lodash \"^3.0.0\"\n", `resolved "https://registry.yarnpkg.com/lodash/-/lodash-3.10.1.tgz#5bf4"`} {
+		if !strings.Contains(written, expected) {
+			t.Errorf("expected %q in\n%s", expected, written)
+		}
+	}
+
+	if strings.Contains(written, "lodash@3.10.1") {
+		t.Errorf("expected the exact descriptor to be replaced in\n%s", written)
+	}
+}
@@ -0,0 +1,161 @@
+package yarn
+
+import (
+	"fmt"
+	"gnarl/semver"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type AdvisoryDatabase struct {
+	advisories map[string][]Advisory
+}
+
+func ReadAdvisoryDatabase(path string) (*AdvisoryDatabase, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open advisory database: %v", err)
+	}
+
+	db := AdvisoryDatabase{advisories: map[string][]Advisory{}}
+	if !info.IsDir() {
+		return &db, db.readFile(path)
+	}
+
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !strings.HasSuffix(file, ".json") {
+			return nil
+		}
+
+		return db.readFile(file)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &db, nil
+}
+
+func (db *AdvisoryDatabase) readFile(file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("cannot read advisory file %s: %v", file, err)
+	}
+
+	advisories, err := ParseAdvisories(data)
+	if err != nil {
+		return fmt.Errorf("cannot parse advisory file %s: %v", file, err)
+	}
+
+	db.Add(advisories...)
+	return nil
+}
+
+func (db *AdvisoryDatabase) Add(advisories ...Advisory) {
+	for _, advisory := range advisories {
+		if advisory.ModuleName == "" || advisory.VulnerableVersions == "" {
+			continue
+		}
+
+		db.advisories[advisory.ModuleName] = append(db.advisories[advisory.ModuleName], advisory)
+	}
+}
+
+func (db *AdvisoryDatabase) Len() int {
+	count := 0
+	for _, advisories := range db.advisories {
+		count += len(advisories)
+	}
+
+	return count
+}
+
+func (db *AdvisoryDatabase) Audit(lock *Lock) []Advisory {
+	var found []Advisory
+	seen := make(map[string]bool)
+
+	for _, locked := range lock.Packages() {
+		for _, advisory := range db.advisories[locked.Name] {
+			key := advisory.auditKey()
+			if seen[key] {
+				continue
+			}
+
+			vulnerable, err := semver.ParseRequest(advisory.VulnerableVersions)
+			if err != nil {
+				log.Printf("skipping advisory %s for %s: %v", advisory.Identifier(), advisory.ModuleName, err)
+				continue
+			}
+
+			if !vulnerable.Matches(locked.Version) {
+				continue
+			}
+
+			if advisory.PatchedVersions == "" {
+				advisory.PatchedVersions = vulnerable.Patches().String()
+			}
+
+			seen[key] = true
+			found = append(found, advisory)
+		}
+	}
+
+	sort.Slice(found, func(p, q int) bool { return found[p].ModuleName < found[q].ModuleName })
+
+	return found
+}
+
+// auditKey tells advisories of a module apart. Advisories without an id may share a url, or have none, so their title
+// and vulnerable range are part of the key.
+func (advisory Advisory) auditKey() string {
+	if advisory.GithubAdvisoryId != "" || advisory.Id != 0 {
+		return fmt.Sprintf("%s@%s", advisory.ModuleName, advisory.Identifier())
+	}
+
+	return fmt.Sprintf("%s@%s %s %s", advisory.ModuleName, advisory.Url, advisory.Title, advisory.VulnerableVersions)
+}
+
+func (db *AdvisoryDatabase) CrossCheck(advisories []Advisory) []string {
+	var findings []string
+
+	for _, advisory := range advisories {
+		if advisory.GithubAdvisoryId == "" || advisory.PatchedVersions == "" {
+			continue
+		}
+
+		patched, err := semver.ParseRequest(advisory.PatchedVersions)
+		if err != nil {
+			findings = append(findings, fmt.Sprintf("%s %s: invalid patched versions %s", advisory.ModuleName, advisory.GithubAdvisoryId, advisory.PatchedVersions))
+			continue
+		}
+
+		for _, known := range db.advisories[advisory.ModuleName] {
+			if known.GithubAdvisoryId != advisory.GithubAdvisoryId {
+				continue
+			}
+
+			vulnerable, err := semver.ParseRequest(known.VulnerableVersions)
+			if err != nil {
+				continue
+			}
+
+			for _, probe := range append(patched.Boundaries(), vulnerable.Boundaries()...) {
+				if patched.Matches(&probe) && vulnerable.Matches(&probe) {
+					findings = append(findings, fmt.Sprintf("%s %s: %s is patched according to npm (%s) but vulnerable according to %s (%s)",
+						advisory.ModuleName, advisory.GithubAdvisoryId, probe.String(), advisory.PatchedVersions, known.Url, known.VulnerableVersions))
+					break
+				}
+			}
+		}
+	}
+
+	return findings
+}
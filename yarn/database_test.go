@@ -0,0 +1,103 @@
+package yarn_test
+
+import (
+	"gnarl/yarn"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+const testLock = `__metadata:
+  version: 6
+  cacheKey: 8
+
+"app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "app@workspace:."
+  dependencies:
+    "@scope/left": ^1.0.0
+    lodash: ^4.17.0
+  languageName: unknown
+  linkType: soft
+
+"@scope/left@npm:^1.0.0":
+  version: 1.2.0
+  resolution: "@scope/left@npm:1.2.0"
+  dependencies:
+    lodash: ^4.17.20
+  checksum: abc
+  languageName: node
+  linkType: hard
+
+"lodash@npm:^4.17.0, lodash@npm:^4.17.20":
+  version: 4.17.20
+  resolution: "lodash@npm:4.17.20"
+  checksum: def
+  languageName: node
+  linkType: hard
+`
+
+func writeTestLock(t *testing.T, lock string) string {
+	directory := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(directory, "yarn.lock"), []byte(lock), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return directory
+}
+
+func TestAdvisoryDatabaseAudit(t *testing.T) {
+	lock, err := yarn.ReadLock(writeTestLock(t, testLock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := yarn.ReadAdvisoryDatabase(writeTestAdvisories(t, `[
+		{"id": 1, "module_name": "lodash", "vulnerable_versions": "<4.17.21"},
+		{"id": 2, "module_name": "lodash", "vulnerable_versions": "<4.17.12"},
+		{"id": 3, "module_name": "@scope/left", "vulnerable_versions": ">=2.0.0"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	advisories := db.Audit(lock)
+	if len(advisories) != 1 || advisories[0].Id != 1 {
+		t.Fatalf("Expected advisory 1 only, got %v", advisories)
+	}
+
+	if advisories[0].PatchedVersions != "^4.17.21" {
+		t.Errorf("Expected derived patched versions ^4.17.21, got %s", advisories[0].PatchedVersions)
+	}
+}
+
+func TestAdvisoryDatabaseAuditWithoutIds(t *testing.T) {
+	lock, err := yarn.ReadLock(writeTestLock(t, testLock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := yarn.ReadAdvisoryDatabase(writeTestAdvisories(t, `[
+		{"module_name": "lodash", "title": "Prototype pollution", "vulnerable_versions": "<4.17.21"},
+		{"module_name": "lodash", "title": "Command injection", "vulnerable_versions": "<4.17.21"},
+		{"module_name": "lodash", "title": "Command injection", "vulnerable_versions": "<4.17.21"},
+		{"module_name": "lodash", "title": "ReDoS", "url": "https://example.com/lodash", "vulnerable_versions": "<4.17.21"},
+		{"module_name": "lodash", "title": "ReDoS", "url": "https://example.com/lodash", "vulnerable_versions": ">=4.17.0 <4.17.22"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if advisories := db.Audit(lock); len(advisories) != 4 {
+		t.Errorf("Expected four distinct advisories, the duplicate dropped, got %v", advisories)
+	}
+}
+
+func writeTestAdvisories(t *testing.T, advisories string) string {
+	file := filepath.Join(t.TempDir(), "advisories.json")
+	if err := ioutil.WriteFile(file, []byte(advisories), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
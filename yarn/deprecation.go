@@ -0,0 +1,128 @@
+package yarn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Deprecation struct {
+	ModuleName string   `json:"module_name"`
+	Version    string   `json:"version"`
+	Message    string   `json:"message"`
+	Dependents []string `json:"dependents,omitempty"`
+}
+
+func FindDeprecations(lock *Lock, metadata Metadata) []Deprecation {
+	packages := lock.Packages()
+
+	var names []string
+	for _, locked := range packages {
+		names = append(names, locked.Name)
+	}
+
+	packuments := fetchPackuments(metadata, names)
+
+	var deprecations []Deprecation
+	for _, locked := range packages {
+		packument, ok := packuments[locked.Name]
+		if !ok {
+			continue
+		}
+
+		manifest, ok := packument.Versions[locked.Version.String()]
+		if !ok || manifest.Deprecated == "" {
+			continue
+		}
+
+		deprecations = append(deprecations, Deprecation{
+			ModuleName: locked.Name,
+			Version:    locked.Version.String(),
+			Message:    manifest.Deprecated,
+			Dependents: lock.Dependents(locked.Key),
+		})
+	}
+
+	return deprecations
+}
+
+func fetchPackuments(metadata Metadata, names []string) map[string]*Packument {
+	const workers = 8
+
+	unique := make(map[string]bool)
+	queue := make(chan string, len(names))
+	for _, name := range names {
+		if !unique[name] {
+			unique[name] = true
+			queue <- name
+		}
+	}
+
+	close(queue)
+
+	packuments := make(map[string]*Packument)
+	var mutex sync.Mutex
+	var wait sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for name := range queue {
+				packument, err := metadata.Packument(name)
+				if err != nil {
+					log.Print(err)
+					continue
+				}
+
+				mutex.Lock()
+				packuments[name] = packument
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	wait.Wait()
+
+	return packuments
+}
+
+func ParseDeprecationsYarn4(output []byte) ([]Deprecation, error) {
+	var deprecations []Deprecation
+	dec := json.NewDecoder(strings.NewReader(string(output)))
+	for {
+		var issue Yarn4Advisory
+		err := dec.Decode(&issue)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot deserialize audit json: %v", err)
+		}
+
+		if id, ok := issue.Children.Id.(string); !ok || !strings.Contains(id, " (deprecation)") {
+			continue
+		}
+
+		versions := issue.Children.TreeVersions
+		if len(versions) == 0 {
+			versions = []string{issue.Children.VulnerableVersions}
+		}
+
+		for _, version := range versions {
+			deprecations = append(deprecations, Deprecation{
+				ModuleName: issue.ModuleName,
+				Version:    version,
+				Message:    issue.Children.Issue,
+				Dependents: issue.Children.Dependents,
+			})
+		}
+	}
+
+	sort.Slice(deprecations, func(p, q int) bool { return deprecations[p].ModuleName < deprecations[q].ModuleName })
+
+	return deprecations, nil
+}
@@ -0,0 +1,52 @@
+package yarn_test
+
+import (
+	"fmt"
+	"gnarl/yarn"
+	"testing"
+)
+
+type fakeMetadata map[string]*yarn.Packument
+
+func (metadata fakeMetadata) Packument(npmPackage string) (*yarn.Packument, error) {
+	if packument, ok := metadata[npmPackage]; ok {
+		return packument, nil
+	}
+
+	return nil, fmt.Errorf("no metadata for %s", npmPackage)
+}
+
+func TestFindDeprecations(t *testing.T) {
+	lock, err := yarn.ReadLock(writeTestLock(t, testLock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deprecations := yarn.FindDeprecations(lock, fakeMetadata{
+		"lodash": {Versions: map[string]yarn.Manifest{
+			"4.17.20": {Version: "4.17.20", Deprecated: "upgrade to 4.17.21"},
+		}},
+	})
+
+	if len(deprecations) != 1 || deprecations[0].ModuleName != "lodash" || deprecations[0].Message != "upgrade to 4.17.21" {
+		t.Fatalf("Unexpected deprecations %v", deprecations)
+	}
+
+	dependents := deprecations[0].Dependents
+	if len(dependents) != 2 || dependents[0] != "@scope/left@npm:1.2.0" || dependents[1] != "app@workspace:." {
+		t.Errorf("Unexpected dependents %v", dependents)
+	}
+}
+
+func TestParseDeprecationsYarn4(t *testing.T) {
+	deprecations, err := yarn.ParseDeprecationsYarn4([]byte(`{"value":"request","children":{"ID":"request (deprecation)","Issue":"request has been deprecated","Severity":"moderate","Vulnerable Versions":"2.88.2","Tree Versions":["2.88.2"],"Dependents":["app@workspace:."]}}
+{"value":"lodash","children":{"ID":1106913,"Issue":"Command Injection in lodash","Severity":"high","Vulnerable Versions":"<4.17.21","Tree Versions":["4.17.20"],"Dependents":["app@workspace:."]}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deprecations) != 1 || deprecations[0].ModuleName != "request" || deprecations[0].Version != "2.88.2" {
+		t.Errorf("Unexpected deprecations %v", deprecations)
+	}
+}
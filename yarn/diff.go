@@ -0,0 +1,305 @@
+package yarn
+
+import (
+	"fmt"
+	"gnarl/semver"
+	"log"
+	"sort"
+	"strings"
+)
+
+type LockDiff struct {
+	Added              []string           `json:"added,omitempty"`
+	Removed            []string           `json:"removed,omitempty"`
+	Changed            []VersionChange    `json:"changed,omitempty"`
+	AddedDescriptors   []string           `json:"added_descriptors,omitempty"`
+	RemovedDescriptors []string           `json:"removed_descriptors,omitempty"`
+	Remapped           []DescriptorChange `json:"remapped,omitempty"`
+	ChecksumChanged    []string           `json:"checksum_changed,omitempty"`
+	DependencyChanges  []DependencyChange `json:"dependency_changes,omitempty"`
+}
+
+type VersionChange struct {
+	Name      string `json:"name"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Distance  string `json:"distance"`
+	Downgrade bool   `json:"downgrade,omitempty"`
+}
+
+type DependencyChange struct {
+	Locator string   `json:"locator"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+type DescriptorChange struct {
+	Descriptor string `json:"descriptor"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+}
+
+func DiffLocks(old, new *Lock) *LockDiff {
+	oldVersions, newVersions := old.versions(), new.versions()
+
+	names := make(map[string]bool)
+	for name := range oldVersions {
+		names[name] = true
+	}
+
+	for name := range newVersions {
+		names[name] = true
+	}
+
+	diff := LockDiff{}
+	for name := range names {
+		removed := subtractVersions(oldVersions[name], newVersions[name])
+		added := subtractVersions(newVersions[name], oldVersions[name])
+
+		for len(removed) > 0 && len(added) > 0 {
+			diff.Changed = append(diff.Changed, VersionChange{
+				Name:      name,
+				From:      removed[0].String(),
+				To:        added[0].String(),
+				Distance:  removed[0].Distance(added[0]),
+				Downgrade: added[0].Compare(removed[0]) < 0,
+			})
+			removed, added = removed[1:], added[1:]
+		}
+
+		for _, version := range removed {
+			diff.Removed = append(diff.Removed, fmt.Sprintf("%s@%s", name, version.String()))
+		}
+
+		for _, version := range added {
+			diff.Added = append(diff.Added, fmt.Sprintf("%s@%s", name, version.String()))
+		}
+	}
+
+	oldEntries, newEntries := old.entries(), new.entries()
+	diff.diffDescriptors(old.locators(), new.locators(), oldEntries)
+	diff.diffEntries(oldEntries, newEntries)
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(p, q int) bool { return diff.Changed[p].Name < diff.Changed[q].Name })
+
+	return &diff
+}
+
+// diffDescriptors reports descriptors moved onto a previously locked package; moves onto a new version are version changes.
+func (diff *LockDiff) diffDescriptors(old, new map[string]string, oldEntries map[string]Resolution) {
+	for descriptor, from := range old {
+		to, ok := new[descriptor]
+		_, existed := oldEntries[to]
+		switch {
+		case !ok:
+			diff.RemovedDescriptors = append(diff.RemovedDescriptors, descriptor)
+		case from != to && existed:
+			diff.Remapped = append(diff.Remapped, DescriptorChange{Descriptor: descriptor, From: from, To: to})
+		}
+	}
+
+	for descriptor := range new {
+		if _, ok := old[descriptor]; !ok {
+			diff.AddedDescriptors = append(diff.AddedDescriptors, descriptor)
+		}
+	}
+
+	sort.Strings(diff.AddedDescriptors)
+	sort.Strings(diff.RemovedDescriptors)
+	sort.Slice(diff.Remapped, func(p, q int) bool { return diff.Remapped[p].Descriptor < diff.Remapped[q].Descriptor })
+}
+
+func (diff *LockDiff) diffEntries(old, new map[string]Resolution) {
+	for locator, before := range old {
+		after, ok := new[locator]
+		if !ok {
+			continue
+		}
+
+		if changedHash(before.Checksum, after.Checksum) || changedHash(before.Integrity, after.Integrity) {
+			diff.ChecksumChanged = append(diff.ChecksumChanged, locator)
+		}
+
+		change := DependencyChange{Locator: locator}
+		for name, request := range before.Dependencies {
+			switch next, ok := after.Dependencies[name]; {
+			case !ok:
+				change.Removed = append(change.Removed, fmt.Sprintf("%s@%s", name, request))
+			case next != request:
+				change.Changed = append(change.Changed, fmt.Sprintf("%s %s -> %s", name, request, next))
+			}
+		}
+
+		for name, request := range after.Dependencies {
+			if _, ok := before.Dependencies[name]; !ok {
+				change.Added = append(change.Added, fmt.Sprintf("%s@%s", name, request))
+			}
+		}
+
+		if len(change.Added) > 0 || len(change.Removed) > 0 || len(change.Changed) > 0 {
+			sort.Strings(change.Added)
+			sort.Strings(change.Removed)
+			sort.Strings(change.Changed)
+			diff.DependencyChanges = append(diff.DependencyChanges, change)
+		}
+	}
+
+	sort.Strings(diff.ChecksumChanged)
+	sort.Slice(diff.DependencyChanges, func(p, q int) bool { return diff.DependencyChanges[p].Locator < diff.DependencyChanges[q].Locator })
+}
+
+// changedHash tells whether a hash both lockfiles record differs.
+func changedHash(before, after string) bool {
+	return before != "" && after != "" && before != after
+}
+
+func (lock *Lock) entries() map[string]Resolution {
+	entries := make(map[string]Resolution)
+	if lock == nil {
+		return entries
+	}
+
+	for _, resolution := range lock.resolutions {
+		if resolution.Resolution != "" {
+			entries[resolution.Resolution] = resolution
+		}
+	}
+
+	return entries
+}
+
+func (lock *Lock) locators() map[string]string {
+	locators := make(map[string]string)
+	if lock == nil {
+		return locators
+	}
+
+	for descriptor, key := range lock.descriptors() {
+		locators[descriptor] = lock.resolutions[key].Resolution
+	}
+
+	return locators
+}
+
+func (lock *Lock) versions() map[string][]*semver.Version {
+	versions := make(map[string][]*semver.Version)
+	if lock == nil {
+		return versions
+	}
+
+	for _, locked := range lock.Packages() {
+		versions[locked.Name] = append(versions[locked.Name], locked.Version)
+	}
+
+	return versions
+}
+
+func subtractVersions(from, subtract []*semver.Version) []*semver.Version {
+	var result []*semver.Version
+
+outer:
+	for _, version := range from {
+		for _, other := range subtract {
+			if version.Compare(other) == 0 {
+				continue outer
+			}
+		}
+
+		result = append(result, version)
+	}
+
+	sort.Slice(result, func(p, q int) bool { return result[p].Compare(result[q]) < 0 })
+
+	return result
+}
+
+func (diff *LockDiff) Empty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0 &&
+		len(diff.AddedDescriptors) == 0 && len(diff.RemovedDescriptors) == 0 && len(diff.Remapped) == 0 &&
+		len(diff.ChecksumChanged) == 0 && len(diff.DependencyChanges) == 0
+}
+
+func (diff *LockDiff) Summary() string {
+	if diff.Empty() {
+		return "no package changes"
+	}
+
+	var parts []string
+	for _, change := range diff.Changed {
+		parts = append(parts, fmt.Sprintf("%s %s -> %s", change.Name, change.From, change.To))
+	}
+
+	if len(diff.Added) > 0 {
+		parts = append(parts, fmt.Sprintf("%d added", len(diff.Added)))
+	}
+
+	if len(diff.Removed) > 0 {
+		parts = append(parts, fmt.Sprintf("%d removed", len(diff.Removed)))
+	}
+
+	if len(diff.ChecksumChanged) > 0 {
+		parts = append(parts, fmt.Sprintf("%d checksums changed", len(diff.ChecksumChanged)))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (diff *LockDiff) Print() {
+	if diff.Empty() {
+		log.Print("no yarn.lock changes")
+		return
+	}
+
+	log.Print("yarn.lock changes")
+
+	for _, descriptor := range diff.RemovedDescriptors {
+		fmt.Fprintf(Output, "    - %s\n", descriptor)
+	}
+
+	for _, descriptor := range diff.AddedDescriptors {
+		fmt.Fprintf(Output, "    + %s\n", descriptor)
+	}
+
+	for _, change := range diff.Remapped {
+		fmt.Fprintf(Output, "    > %s: %s -> %s\n", change.Descriptor, change.From, change.To)
+	}
+
+	for _, change := range diff.Changed {
+		direction := "upgrade"
+		if change.Downgrade {
+			direction = "downgrade"
+		}
+
+		fmt.Fprintf(Output, "    ~ %s %s -> %s (%s %s)\n", change.Name, change.From, change.To, change.Distance, direction)
+	}
+
+	for _, locator := range diff.Removed {
+		fmt.Fprintf(Output, "    - %s\n", locator)
+	}
+
+	for _, locator := range diff.Added {
+		fmt.Fprintf(Output, "    + %s\n", locator)
+	}
+
+	for _, change := range diff.DependencyChanges {
+		fmt.Fprintf(Output, "    %s dependencies\n", change.Locator)
+		for _, dependency := range change.Removed {
+			fmt.Fprintf(Output, "        - %s\n", dependency)
+		}
+
+		for _, dependency := range change.Added {
+			fmt.Fprintf(Output, "        + %s\n", dependency)
+		}
+
+		for _, dependency := range change.Changed {
+			fmt.Fprintf(Output, "        ~ %s\n", dependency)
+		}
+	}
+
+	for _, locator := range diff.ChecksumChanged {
+		log.Printf("checksum changed without version change: %s", locator)
+	}
+}
@@ -0,0 +1,52 @@
+package yarn_test
+
+import (
+	"gnarl/yarn"
+	"strings"
+	"testing"
+)
+
+func TestDiffLocks(t *testing.T) {
+	old, err := yarn.ParseLock([]byte(testLock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed := strings.NewReplacer("4.17.20", "4.17.21", "checksum: abc", "checksum: xyz", "lodash: ^4.17.20", "lodash: ^4.17.21").Replace(testLock)
+	new, err := yarn.ParseLock([]byte(changed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff := yarn.DiffLocks(old, new)
+
+	if len(diff.Changed) != 1 || diff.Changed[0].Name != "lodash" || diff.Changed[0].Distance != "patch" || diff.Changed[0].Downgrade {
+		t.Errorf("Expected lodash patch upgrade, got %v", diff.Changed)
+	}
+
+	if len(diff.ChecksumChanged) != 1 || diff.ChecksumChanged[0] != "@scope/left@npm:1.2.0" {
+		t.Errorf("Expected checksum change of @scope/left, got %v", diff.ChecksumChanged)
+	}
+
+	if len(diff.DependencyChanges) != 1 || len(diff.DependencyChanges[0].Changed) != 1 {
+		t.Errorf("Expected dependency change of @scope/left, got %v", diff.DependencyChanges)
+	}
+
+	if len(diff.Added) != 0 || len(diff.Removed) != 0 || len(diff.Remapped) != 0 {
+		t.Errorf("Unexpected additions, removals or remaps: %v", diff)
+	}
+}
+
+func TestDiffClassicLocksIntegrity(t *testing.T) {
+	old := mustParseLock(t, classicLock)
+	new := mustParseLock(t, strings.Replace(classicLock, "sha512-fsevents==", "sha512-tampered==", 1))
+
+	diff := yarn.DiffLocks(old, new)
+	if len(diff.ChecksumChanged) != 1 || diff.ChecksumChanged[0] != "fsevents@npm:2.3.2" {
+		t.Errorf("Expected integrity change of fsevents, got %v", diff.ChecksumChanged)
+	}
+
+	if len(diff.Changed) != 0 || len(diff.Added) != 0 || len(diff.Removed) != 0 {
+		t.Errorf("Unexpected changes: %v", diff)
+	}
+}
@@ -0,0 +1,159 @@
+package yarn
+
+import (
+	"sort"
+	"strings"
+)
+
+func (lock *Lock) descriptors() map[string]string {
+	descriptors := make(map[string]string)
+
+	for key := range lock.resolutions {
+		for _, descriptor := range strings.Split(key, ", ") {
+			descriptors[descriptor] = key
+		}
+	}
+
+	return descriptors
+}
+
+func resolveDependency(descriptors map[string]string, name string, request string) (string, bool) {
+	if key, ok := descriptors[name+"@"+request]; ok {
+		return key, true
+	}
+
+	key, ok := descriptors[name+"@npm:"+request]
+	return key, ok
+}
+
+func (lock *Lock) Dependents(key string) []string {
+	descriptors := lock.descriptors()
+
+	var dependents []string
+	for parentKey, parent := range lock.resolutions {
+		for name, request := range parent.Dependencies {
+			if resolved, ok := resolveDependency(descriptors, name, request); ok && resolved == key {
+				dependents = append(dependents, lock.resolutions[parentKey].Resolution)
+				break
+			}
+		}
+	}
+
+	sort.Strings(dependents)
+
+	return dependents
+}
+
+// edges lists the lock entries each entry depends on and the entries depending on it, by key.
+func (lock *Lock) edges() (map[string][]string, map[string][]string) {
+	descriptors := lock.descriptors()
+	dependencies := make(map[string][]string)
+	dependents := make(map[string][]string)
+
+	for key, resolution := range lock.resolutions {
+		for name, request := range resolution.Dependencies {
+			if dependency, ok := resolveDependency(descriptors, name, request); ok {
+				dependencies[key] = append(dependencies[key], dependency)
+				dependents[dependency] = append(dependents[dependency], key)
+			}
+		}
+	}
+
+	return dependencies, dependents
+}
+
+// Why lists the dependency chains, as locators, from the entries nothing depends on, usually the workspaces, to the
+// entries matching the pattern. Shorter chains come first; the second result tells whether there were more than limit.
+func (lock *Lock) Why(pattern *ResetPattern, limit int) ([][]string, bool) {
+	dependencies, dependents := lock.edges()
+
+	// heights are the distances from the closest entry nothing depends on.
+	heights := make(map[string]int)
+	var queue []string
+	for key := range lock.resolutions {
+		if len(dependents[key]) == 0 {
+			heights[key] = 0
+			queue = append(queue, key)
+		}
+	}
+
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		for _, child := range dependencies[key] {
+			if _, seen := heights[child]; !seen {
+				heights[child] = heights[key] + 1
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	// Partial chains are extended in the order of the length of their shortest completion, so that the fan-in of
+	// popular packages only costs the chains that are listed.
+	type partial struct {
+		path []string
+		rank []int
+	}
+
+	pending := make(map[int][]partial)
+	for i, locked := range lock.Matching(pattern) {
+		if height, ok := heights[locked.Key]; ok {
+			pending[height+1] = append(pending[height+1], partial{[]string{locked.Key}, []int{i}})
+		}
+	}
+
+	var chains [][]string
+	for length := 1; len(pending) > 0; length++ {
+		for len(pending[length]) > 0 {
+			// The completions of a length come in the order of the indexes of the match and the parents taken, which
+			// is breadth first order, and depth first extension keeps few partial chains around.
+			next := 0
+			for i, candidate := range pending[length] {
+				if lessRank(candidate.rank, pending[length][next].rank) {
+					next = i
+				}
+			}
+
+			current := pending[length][next]
+			pending[length] = append(pending[length][:next], pending[length][next+1:]...)
+
+			parents := dependents[current.path[0]]
+			if len(parents) == 0 {
+				if len(chains) == limit {
+					return chains, true
+				}
+
+				chain := make([]string, len(current.path))
+				for i, key := range current.path {
+					chain[i] = lock.resolutions[key].Resolution
+				}
+
+				chains = append(chains, chain)
+				continue
+			}
+
+			sort.Strings(parents)
+			for i, parent := range parents {
+				if height, ok := heights[parent]; ok && !containsString(current.path, parent) {
+					total := len(current.path) + 1 + height
+					rank := append(append([]int{}, current.rank...), i)
+					pending[total] = append(pending[total], partial{append([]string{parent}, current.path...), rank})
+				}
+			}
+		}
+
+		delete(pending, length)
+	}
+
+	return chains, false
+}
+
+func lessRank(p []int, q []int) bool {
+	for i := 0; i < len(p) && i < len(q); i++ {
+		if p[i] != q[i] {
+			return p[i] < q[i]
+		}
+	}
+
+	return len(p) < len(q)
+}
@@ -0,0 +1,54 @@
+package yarn_test
+
+import (
+	"fmt"
+	"gnarl/yarn"
+	"strings"
+	"testing"
+)
+
+// fanInLock locks layers of packages that each depend on all packages of the next layer, ending in target, so that
+// there are width^depth chains to it.
+func fanInLock(depth int, width int) string {
+	var b strings.Builder
+	b.WriteString("\"app@workspace:.\":\n  version: 0.0.0-use.local\n  resolution: \"app@workspace:.\"\n  dependencies:\n")
+	for p := 0; p < width; p++ {
+		fmt.Fprintf(&b, "    p0-%d: ^1.0.0\n", p)
+	}
+
+	b.WriteString("  languageName: unknown\n  linkType: soft\n")
+	for layer := 0; layer < depth; layer++ {
+		for p := 0; p < width; p++ {
+			fmt.Fprintf(&b, "\n\"p%d-%d@npm:^1.0.0\":\n  version: 1.0.0\n  resolution: \"p%d-%d@npm:1.0.0\"\n  dependencies:\n", layer, p, layer, p)
+			if layer == depth-1 {
+				b.WriteString("    target: ^1.0.0\n")
+			}
+
+			for q := 0; q < width && layer < depth-1; q++ {
+				fmt.Fprintf(&b, "    p%d-%d: ^1.0.0\n", layer+1, q)
+			}
+
+			b.WriteString("  languageName: node\n  linkType: hard\n")
+		}
+	}
+
+	b.WriteString("\n\"target@npm:^1.0.0\":\n  version: 1.0.0\n  resolution: \"target@npm:1.0.0\"\n  languageName: node\n  linkType: hard\n")
+	return b.String()
+}
+
+func TestWhyFanIn(t *testing.T) {
+	lock := mustParseLock(t, fanInLock(16, 6))
+	pattern, err := yarn.ParseResetPattern("target")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chains, truncated := lock.Why(pattern, 5)
+	if len(chains) != 5 || !truncated {
+		t.Fatalf("expected five chains and truncation, got %d (truncated %v)", len(chains), truncated)
+	}
+
+	if chain := strings.Join(chains[0], " > "); !strings.HasPrefix(chain, "app@workspace:. > p0-0@npm:1.0.0 > p1-0@npm:1.0.0") || len(chains[0]) != 18 {
+		t.Errorf("unexpected first chain %s", chain)
+	}
+}
@@ -0,0 +1,371 @@
+package yarn
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// PackageLicense is the license of a locked package, an SPDX expression from its manifest, and how the license
+// policy judges it. Packages without a Source have no license data, which only an allow list rejects. Direct
+// packages are dependencies of a workspace, transitive ones are pulled in by the direct packages listed in Via.
+type PackageLicense struct {
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	Locator   string   `json:"locator"`
+	License   string   `json:"license"`
+	Source    string   `json:"source,omitempty"`
+	Direct    bool     `json:"direct"`
+	Via       []string `json:"via,omitempty"`
+	Violation string   `json:"violation,omitempty"`
+	Exempt    bool     `json:"exempt,omitempty"`
+}
+
+const (
+	LicenseDenied     = "denied"
+	LicenseNotAllowed = "not allowed"
+	LicenseUnknown    = "unknown"
+)
+
+// LicensePolicy allows and denies SPDX license identifiers, an empty allow list allows all licenses not denied.
+// Packages matching the exceptions are not judged.
+type LicensePolicy struct {
+	Allow      []string
+	Deny       []string
+	Exceptions []*ResetPattern
+}
+
+// Licenses reads the license of every locked package from its manifest in the yarn cache, the lockfile or else the
+// registry metadata, and judges it by the policy. The cache and the metadata may be nil.
+func (lock *Lock) Licenses(cache *Cache, metadata Metadata, policy LicensePolicy) []PackageLicense {
+	_, dependents := lock.edges()
+	workspaces := make(map[string]bool)
+	for key, resolution := range lock.resolutions {
+		if _, reference := splitLocator(resolution.Resolution); strings.HasPrefix(reference, "workspace:") {
+			workspaces[key] = true
+		}
+	}
+
+	var licenses []PackageLicense
+	for key, resolution := range lock.resolutions {
+		name, reference := splitLocator(resolution.Resolution)
+		if key == "__metadata" || workspaces[key] || !hasArchive(resolution.Resolution) || strings.HasPrefix(reference, "patch:") {
+			continue
+		}
+
+		license := PackageLicense{Name: name, Version: resolution.Version, Locator: resolution.Resolution}
+		license.License, license.Source = lock.readLicense(resolution, cache, metadata)
+		license.Direct, license.Via = lock.directDependents(key, dependents, workspaces)
+
+		for _, exception := range policy.Exceptions {
+			license.Exempt = license.Exempt || exception.matches(key, resolution)
+		}
+
+		switch {
+		case license.Exempt:
+		case license.Source != "":
+			license.Violation = policy.judge(license.License)
+		case len(policy.Allow) > 0:
+			license.Violation = LicenseUnknown
+		}
+
+		licenses = append(licenses, license)
+	}
+
+	sort.Slice(licenses, func(p, q int) bool { return licenses[p].Locator < licenses[q].Locator })
+	return licenses
+}
+
+func (lock *Lock) readLicense(resolution Resolution, cache *Cache, metadata Metadata) (string, string) {
+	name, reference := splitLocator(resolution.Resolution)
+	if cache != nil {
+		if archive, ok := cache.Archive(resolution, lock.CacheKey()); ok {
+			if manifest, err := readArchiveManifest(archive, name); err == nil {
+				return manifest.license(), "cache"
+			}
+		}
+	}
+
+	if resolution.License != "" {
+		return resolution.License, "lockfile"
+	}
+
+	// Abbreviated packuments have no license at all, which is no data rather than an unknown license.
+	if metadata != nil && strings.HasPrefix(reference, "npm:") {
+		if packument, err := metadata.Packument(name); err == nil {
+			if manifest, ok := packument.Versions[resolution.Version]; ok && (manifest.License != nil || manifest.Licenses != nil) {
+				return manifest.license(), "registry"
+			}
+		}
+	}
+
+	return "", ""
+}
+
+// readArchiveManifest reads the package.json of a package from its yarn archive, which holds the package in
+// node_modules/<name>.
+func readArchiveManifest(archive string, name string) (*Manifest, error) {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if file.Name != "node_modules/"+name+"/package.json" {
+			continue
+		}
+
+		content, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		defer content.Close()
+
+		data, err := ioutil.ReadAll(content)
+		if err != nil {
+			return nil, err
+		}
+
+		var manifest Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("cannot deserialize package.json of %s: %v", name, err)
+		}
+
+		return &manifest, nil
+	}
+
+	return nil, fmt.Errorf("no package.json for %s in %s", name, archive)
+}
+
+// license is the SPDX expression of the manifest. The deprecated {"type": ...} objects and licenses arrays are
+// turned into one, the licenses of an array being alternatives.
+func (manifest Manifest) license() string {
+	var license string
+	if json.Unmarshal(manifest.License, &license) == nil {
+		return license
+	}
+
+	var typed struct {
+		Type string `json:"type"`
+	}
+
+	if json.Unmarshal(manifest.License, &typed) == nil && typed.Type != "" {
+		return typed.Type
+	}
+
+	var alternatives []struct {
+		Type string `json:"type"`
+	}
+
+	if json.Unmarshal(manifest.Licenses, &alternatives) != nil {
+		return ""
+	}
+
+	var types []string
+	for _, alternative := range alternatives {
+		if alternative.Type != "" {
+			types = append(types, alternative.Type)
+		}
+	}
+
+	if len(types) > 1 {
+		return "(" + strings.Join(types, " OR ") + ")"
+	}
+
+	return strings.Join(types, "")
+}
+
+// directDependents tells whether a workspace depends on the entry, and otherwise which direct dependencies pull it in.
+func (lock *Lock) directDependents(key string, dependents map[string][]string, workspaces map[string]bool) (bool, []string) {
+	for _, dependent := range dependents[key] {
+		if workspaces[dependent] {
+			return true, nil
+		}
+	}
+
+	seen := map[string]bool{key: true}
+	queue := []string{key}
+	var via []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[current] {
+			if seen[dependent] || workspaces[dependent] {
+				continue
+			}
+
+			seen[dependent] = true
+			queue = append(queue, dependent)
+			for _, parent := range dependents[dependent] {
+				if workspaces[parent] && !containsString(via, lock.resolutions[dependent].Resolution) {
+					via = append(via, lock.resolutions[dependent].Resolution)
+				}
+			}
+		}
+	}
+
+	sort.Strings(via)
+	return false, via
+}
+
+// judge returns why the policy rejects a license expression, if it does. Alternatives need one acceptable license,
+// conjunctions all of them.
+func (policy LicensePolicy) judge(license string) string {
+	if len(policy.Allow) == 0 && len(policy.Deny) == 0 {
+		return ""
+	}
+
+	expression, err := parseLicense(license)
+	if err != nil {
+		return LicenseUnknown
+	}
+
+	if policy.accepts(expression, true) {
+		return ""
+	}
+
+	if !policy.accepts(expression, false) {
+		return LicenseDenied
+	}
+
+	return LicenseNotAllowed
+}
+
+// accepts evaluates the expression, with the allow list only when asked to, so that denied licenses can be told
+// from those that are merely not allowed.
+func (policy LicensePolicy) accepts(expression *licenseExpression, allowList bool) bool {
+	switch expression.operator {
+	case "OR":
+		return policy.accepts(expression.left, allowList) || policy.accepts(expression.right, allowList)
+	case "AND":
+		return policy.accepts(expression.left, allowList) && policy.accepts(expression.right, allowList)
+	}
+
+	whole := expression.license
+	if expression.exception != "" {
+		whole += " WITH " + expression.exception
+	}
+
+	switch {
+	case containsFold(policy.Allow, whole):
+		return true
+	case containsFold(policy.Deny, whole) || containsFold(policy.Deny, expression.license):
+		return false
+	default:
+		return !allowList || len(policy.Allow) == 0 || containsFold(policy.Allow, expression.license)
+	}
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// licenseExpression is an SPDX license expression: a license, optionally WITH an exception, or two expressions
+// joined by AND or OR.
+type licenseExpression struct {
+	license   string
+	exception string
+	operator  string
+	left      *licenseExpression
+	right     *licenseExpression
+}
+
+func parseLicense(license string) (*licenseExpression, error) {
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(license))
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("no license")
+	}
+
+	parser := licenseParser{tokens: tokens}
+	expression, err := parser.or()
+	if err == nil && parser.position < len(tokens) {
+		err = fmt.Errorf("unexpected %s in %s", tokens[parser.position], license)
+	}
+
+	return expression, err
+}
+
+type licenseParser struct {
+	tokens   []string
+	position int
+}
+
+func (parser *licenseParser) next(operator string) bool {
+	if parser.position < len(parser.tokens) && strings.EqualFold(parser.tokens[parser.position], operator) {
+		parser.position++
+		return true
+	}
+
+	return false
+}
+
+func (parser *licenseParser) or() (*licenseExpression, error) {
+	return parser.binary("OR", parser.and)
+}
+
+func (parser *licenseParser) and() (*licenseExpression, error) {
+	return parser.binary("AND", parser.term)
+}
+
+func (parser *licenseParser) binary(operator string, operand func() (*licenseExpression, error)) (*licenseExpression, error) {
+	left, err := operand()
+	for err == nil && parser.next(operator) {
+		var right *licenseExpression
+		if right, err = operand(); err == nil {
+			left = &licenseExpression{operator: operator, left: left, right: right}
+		}
+	}
+
+	return left, err
+}
+
+func (parser *licenseParser) term() (*licenseExpression, error) {
+	if parser.next("(") {
+		expression, err := parser.or()
+		if err == nil && !parser.next(")") {
+			err = fmt.Errorf("missing )")
+		}
+
+		return expression, err
+	}
+
+	license, err := parser.identifier()
+	if err != nil {
+		return nil, err
+	}
+
+	expression := &licenseExpression{license: license}
+	if parser.next("WITH") {
+		expression.exception, err = parser.identifier()
+	}
+
+	return expression, err
+}
+
+func (parser *licenseParser) identifier() (string, error) {
+	if parser.position >= len(parser.tokens) {
+		return "", fmt.Errorf("expected a license")
+	}
+
+	token := parser.tokens[parser.position]
+	for _, reserved := range []string{"(", ")", "AND", "OR", "WITH"} {
+		if strings.EqualFold(token, reserved) {
+			return "", fmt.Errorf("expected a license, got %s", token)
+		}
+	}
+
+	parser.position++
+	return token, nil
+}
@@ -0,0 +1,174 @@
+package yarn_test
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"gnarl/yarn"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const licenseLock = `__metadata:
+  version: 8
+  cacheKey: 10c0
+
+"app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "app@workspace:."
+  dependencies:
+    left: "npm:^1.0.0"
+    right: "npm:^1.0.0"
+  languageName: unknown
+  linkType: soft
+
+"left@npm:^1.0.0":
+  version: 1.0.0
+  resolution: "left@npm:1.0.0"
+  dependencies:
+    copyleft: "npm:^2.0.0"
+  languageName: node
+  linkType: hard
+
+"right@npm:^1.0.0":
+  version: 1.0.0
+  resolution: "right@npm:1.0.0"
+  languageName: node
+  linkType: hard
+
+"copyleft@npm:^2.0.0":
+  version: 2.0.0
+  resolution: "copyleft@npm:2.0.0"
+  languageName: node
+  linkType: hard
+
+"mystery@npm:^1.0.0":
+  version: 1.0.0
+  resolution: "mystery@npm:1.0.0"
+  languageName: node
+  linkType: hard
+`
+
+func writeArchive(t *testing.T, folder string, name string, manifest string) {
+	file, err := os.Create(filepath.Join(folder, name+"-npm-1.0.0-0123456789.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	entry, err := archive.Create("node_modules/" + name + "/package.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := entry.Write([]byte(manifest)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLicenses(t *testing.T) {
+	folder := t.TempDir()
+	writeArchive(t, folder, "left", `{"name": "left", "license": "(MIT OR GPL-3.0-only)"}`)
+	writeArchive(t, folder, "right", `{"name": "right", "licenses": [{"type": "Apache-2.0"}]}`)
+
+	metadata := fakeMetadata{
+		"copyleft": {Versions: map[string]yarn.Manifest{
+			"2.0.0": {Version: "2.0.0", License: json.RawMessage(`"GPL-3.0-only"`)},
+		}},
+	}
+
+	exception, err := yarn.ParseResetPattern("mystery")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy := yarn.LicensePolicy{Allow: []string{"MIT", "Apache-2.0"}, Deny: []string{"GPL-3.0-only"}, Exceptions: []*yarn.ResetPattern{exception}}
+	licenses := mustParseLock(t, licenseLock).Licenses(&yarn.Cache{Folder: folder}, metadata, policy)
+
+	expected := []yarn.PackageLicense{
+		{Name: "copyleft", Version: "2.0.0", Locator: "copyleft@npm:2.0.0", License: "GPL-3.0-only", Source: "registry", Via: []string{"left@npm:1.0.0"}, Violation: yarn.LicenseDenied},
+		{Name: "left", Version: "1.0.0", Locator: "left@npm:1.0.0", License: "(MIT OR GPL-3.0-only)", Source: "cache", Direct: true},
+		{Name: "mystery", Version: "1.0.0", Locator: "mystery@npm:1.0.0", Exempt: true},
+		{Name: "right", Version: "1.0.0", Locator: "right@npm:1.0.0", License: "Apache-2.0", Source: "cache", Direct: true},
+	}
+
+	if !reflect.DeepEqual(licenses, expected) {
+		t.Errorf("expected %+v, got %+v", expected, licenses)
+	}
+}
+
+func TestLicensePolicy(t *testing.T) {
+	policy := yarn.LicensePolicy{Allow: []string{"MIT", "Apache-2.0 WITH LLVM-exception"}, Deny: []string{"GPL-3.0-only"}}
+	for license, expected := range map[string]string{
+		"MIT":                            "",
+		"mit":                            "",
+		"MIT AND (BSD-2-Clause OR MIT)":  "",
+		"Apache-2.0 WITH LLVM-exception": "",
+		"Apache-2.0":                     yarn.LicenseNotAllowed,
+		"MIT AND GPL-3.0-only":           yarn.LicenseDenied,
+		"BSD-2-Clause":                   yarn.LicenseNotAllowed,
+		"SEE LICENSE IN LICENSE.md":      yarn.LicenseUnknown,
+		"(MIT":                           yarn.LicenseUnknown,
+		"":                               yarn.LicenseUnknown,
+	} {
+		lock := mustParseLock(t, "__metadata:\n  version: 8\n\n\"pkg@npm:^1.0.0\":\n  version: 1.0.0\n  resolution: \"pkg@npm:1.0.0\"\n")
+		metadata := fakeMetadata{"pkg": {Versions: map[string]yarn.Manifest{"1.0.0": {License: json.RawMessage(`"` + license + `"`)}}}}
+		if actual := lock.Licenses(nil, metadata, policy)[0].Violation; actual != expected {
+			t.Errorf("expected %q to be judged %q, got %q", license, expected, actual)
+		}
+	}
+}
+
+func TestLicensesOfNpmLock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		license := ""
+		if r.Header.Get("Accept") == "application/json" {
+			license = `, "license": "ISC"`
+		}
+
+		switch r.URL.Path {
+		case "/left":
+			w.Write([]byte(`{"name": "left", "versions": {"1.2.0": {"version": "1.2.0"` + license + `}}}`))
+		case "/lodash":
+			w.Write([]byte(`{"name": "lodash", "versions": {"3.10.1": {"version": "3.10.1"}, "4.17.20": {"version": "4.17.20"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	policy := yarn.LicensePolicy{Allow: []string{"MIT"}}
+	for _, full := range []bool{false, true} {
+		registry := yarn.NewRegistry(&yarn.Config{NpmRegistryServer: server.URL})
+		registry.Full = full
+		licenses := mustParseLock(t, npmLock).Licenses(nil, &yarn.CachedMetadata{Directory: t.TempDir(), Upstream: registry}, policy)
+
+		actual := make(map[string]string)
+		for _, license := range licenses {
+			actual[license.Locator] = license.License + "|" + license.Source + "|" + license.Violation
+		}
+
+		expected := map[string]string{
+			"left@npm:1.2.0":     "||" + yarn.LicenseUnknown,
+			"lodash@npm:3.10.1":  "||" + yarn.LicenseUnknown,
+			"lodash@npm:4.17.20": "MIT|lockfile|",
+		}
+
+		if full {
+			expected["left@npm:1.2.0"] = "ISC|registry|" + yarn.LicenseNotAllowed
+		}
+
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("expected %v with full packuments %v, got %v", expected, full, actual)
+		}
+	}
+}
@@ -1,22 +1,30 @@
 package yarn
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"gnarl/semver"
+	"io"
 	"io/fs"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	yaml2 "gopkg.in/yaml.v2"
 )
 
+var Output io.Writer = os.Stdout
+
 type Lock struct {
 	dirty       bool
 	resolutions map[string]Resolution
 	suggestions map[string]*semver.Version
+	advisories  map[string][]string
+	manager     Manager
+	format      Lockfile
 }
 
 type Resolution struct {
@@ -31,40 +39,118 @@ type Resolution struct {
 	Checksum             string                    `yaml:"checksum,omitempty"`
 	LanguageName         string                    `yaml:"languageName,omitempty"`
 	LinkType             string                    `yaml:"linkType,omitempty"`
+
+	// Resolved and Integrity are the tarball url and its hash in yarn classic and npm lockfiles.
+	Resolved  string `yaml:"resolved,omitempty"`
+	Integrity string `yaml:"integrity,omitempty"`
+
+	// License is the license npm records in package-lock.json.
+	License string `yaml:"-"`
 }
 
 type DependencyMeta struct {
 	Optional bool `yaml:"optional,omitempty"`
 }
 
-func yarnLock(directory string) string {
-	return fmt.Sprintf("%s/yarn.lock", directory)
+type LockedPackage struct {
+	Name    string
+	Version *semver.Version
+	Key     string
 }
 
+// ReadLock reads the lockfile of a project. Classic lockfiles get a workspace entry for the project from package.json,
+// like berry lockfiles have, so that dependency chains start somewhere.
 func ReadLock(directory string) (*Lock, error) {
-	reader, err := os.Open(yarnLock(directory))
+	format, err := DetectLockfile(directory)
 	if err != nil {
-		return nil, fmt.Errorf("cannot open yarn.lock: %v", err)
+		return nil, err
 	}
 
-	defer reader.Close()
+	return readLock(directory, format.Name())
+}
 
-	yaml, err := ioutil.ReadAll(reader)
+// ReadLockFormat reads the lockfile of the given format, failing when the file holds another one.
+func ReadLockFormat(directory string, format Lockfile) (*Lock, error) {
+	lock, err := readLock(directory, format.Name())
 	if err != nil {
-		return nil, fmt.Errorf("cannot read yarn.lock: %v", err)
+		return nil, err
+	}
+
+	if manager := lock.Format().Manager(); manager != format.Manager() {
+		return nil, fmt.Errorf("%s is a %s lockfile, not a %s one", format.Name(), manager, format.Manager())
+	}
+
+	return lock, nil
+}
+
+func readLock(directory string, name string) (*Lock, error) {
+	lock, err := ReadLockFile(filepath.Join(directory, name))
+	if err != nil || lock.Format().Manager() != Classic {
+		return lock, err
 	}
 
-	lock := Lock{resolutions: map[string]Resolution{}, suggestions: map[string]*semver.Version{}}
-	err = yaml2.Unmarshal(yaml, lock.resolutions)
+	if project, err := ReadPackage(directory); err == nil {
+		lock.addWorkspace(project)
+	}
+
+	return lock, nil
+}
+
+func ReadLockFile(path string) (*Lock, error) {
+	reader, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("cannot deserialize yarn.lock: %v", err)
+		return nil, fmt.Errorf("cannot open %s: %v", filepath.Base(path), err)
+	}
+
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", filepath.Base(path), err)
+	}
+
+	return ParseLock(data)
+}
+
+// ParseLock reads lockfiles of any supported format.
+func ParseLock(data []byte) (*Lock, error) {
+	return detectFormat(data).Parse(data)
+}
+
+func (lock *Lock) Packages() []LockedPackage {
+	var packages []LockedPackage
+
+	for key, resolution := range lock.resolutions {
+		name, reference := splitLocator(resolution.Resolution)
+		if !strings.HasPrefix(reference, "npm:") {
+			continue
+		}
+
+		version, err := semver.ParseVersion(resolution.Version)
+		if err != nil {
+			log.Printf("skipping %s: %v", key, err)
+			continue
+		}
+
+		packages = append(packages, LockedPackage{Name: name, Version: version, Key: key})
 	}
 
-	if len(lock.resolutions) == 0 {
-		return nil, fmt.Errorf("no entries found in yarn.lock")
+	sort.Slice(packages, func(p, q int) bool { return packages[p].Key < packages[q].Key })
+
+	return packages
+}
+
+func splitLocator(locator string) (string, string) {
+	if len(locator) < 2 {
+		return locator, ""
+	}
+
+	loc := strings.Index(locator[1:], "@")
+	if loc < 0 {
+		return locator, ""
 	}
 
-	return &lock, nil
+	return locator[:loc+1], locator[loc+2:]
 }
 
 func (lock *Lock) Has(npmPackage string, request string) bool {
@@ -74,7 +160,7 @@ func (lock *Lock) Has(npmPackage string, request string) bool {
 				continue
 			}
 
-			if request == "*" || request == value {
+			if request == "*" || request == value || "npm:"+request == value {
 				return true
 			}
 		}
@@ -83,7 +169,9 @@ func (lock *Lock) Has(npmPackage string, request string) bool {
 	return false
 }
 
-func (lock *Lock) Fix(npmPackage string, safeVersions *semver.Request) {
+// Fix resets the entries of a package that can move to a safe version and suggests resolutions for the others,
+// remembering the advisories each suggestion is for.
+func (lock *Lock) Fix(npmPackage string, safeVersions *semver.Request, advisories ...string) {
 	resolutions, _ := lock.read(npmPackage)
 	if len(resolutions) == 0 {
 		return
@@ -106,9 +194,12 @@ func (lock *Lock) Fix(npmPackage string, safeVersions *semver.Request) {
 			log.Printf(`No fix for %s`, npmPackageRequest)
 		case lock.suggestions[npmPackageRequest] == nil:
 			lock.suggestions[npmPackageRequest] = closest
+			lock.addAdvisories(npmPackageRequest, advisories)
 		case lock.suggestions[npmPackageRequest].AtLeast().Matches(closest):
 			lock.suggestions[npmPackageRequest] = closest
+			lock.addAdvisories(npmPackageRequest, advisories)
 		default:
+			lock.addAdvisories(npmPackageRequest, advisories)
 		}
 	}
 
@@ -117,6 +208,42 @@ func (lock *Lock) Fix(npmPackage string, safeVersions *semver.Request) {
 	}
 }
 
+func (lock *Lock) addAdvisories(suggestion string, advisories []string) {
+	for _, advisory := range advisories {
+		known := false
+		for _, existing := range lock.advisories[suggestion] {
+			known = known || existing == advisory
+		}
+
+		if !known {
+			lock.advisories[suggestion] = append(lock.advisories[suggestion], advisory)
+		}
+	}
+}
+
+// Advisories returns the advisories a suggested override, keyed like Suggestions, is for.
+func (lock *Lock) Advisories(key string) []string {
+	var suggestions []string
+	for suggestion := range lock.advisories {
+		if lock.suggestionKey(suggestion) == key {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	sort.Strings(suggestions)
+
+	var advisories []string
+	for _, suggestion := range suggestions {
+		for _, advisory := range lock.advisories[suggestion] {
+			if !containsString(advisories, advisory) {
+				advisories = append(advisories, advisory)
+			}
+		}
+	}
+
+	return advisories
+}
+
 func (lock *Lock) Reset(npmPackage string) {
 	var keys []string
 
@@ -239,39 +366,113 @@ func (lock *Lock) shrink(npmPackage string) {
 	}
 }
 
+// SetManager decides the syntax of the suggested overrides, by default those of the manager of the lockfile.
+func (lock *Lock) SetManager(manager Manager) {
+	lock.manager = manager
+}
+
+func (lock *Lock) Manager() Manager {
+	if lock.manager == "" {
+		return lock.Format().Manager()
+	}
+
+	return lock.manager
+}
+
+// suggestionKey turns the name@range of a suggestion into the override key of the manager.
+func (lock *Lock) suggestionKey(suggestion string) string {
+	name, rng := splitLocator(suggestion)
+	return lock.Manager().OverrideKey(name, rng)
+}
+
+// Suggestions returns the suggested overrides, keyed in the syntax of the manager.
+func (lock *Lock) Suggestions() map[string]string {
+	versions := make(map[string]*semver.Version)
+	for suggestion, version := range lock.suggestions {
+		key := lock.suggestionKey(suggestion)
+		if versions[key] == nil || version.Compare(versions[key]) > 0 {
+			versions[key] = version
+		}
+	}
+
+	suggestions := make(map[string]string)
+	for key, version := range versions {
+		suggestions[key] = "^" + version.String()
+	}
+
+	return suggestions
+}
+
 func (lock *Lock) printSuggestions() {
-	if len(lock.suggestions) == 0 {
+	suggestions := lock.Suggestions()
+	if len(suggestions) == 0 {
 		return
 	}
 
 	var keys []string
 
-	for key := range lock.suggestions {
+	for key := range suggestions {
 		keys = append(keys, key)
 	}
 
 	sort.Slice(keys, func(p, q int) bool { return keys[p] < keys[q] })
 
-	log.Printf("Suggested resolutions")
+	log.Printf("Suggested %s", lock.Manager().OverrideField())
 
 	for _, key := range keys {
-		fmt.Printf("    \"%s\": \"^%s\",\n", key, lock.suggestions[key].String())
+		fmt.Fprintf(Output, "    \"%s\": \"%s\",\n", key, suggestions[key])
+	}
+}
+
+func (lock *Lock) Hash() (string, error) {
+	yaml, err := yaml2.Marshal(lock.resolutions)
+	if err != nil {
+		return "", fmt.Errorf("cannot serialize yarn.lock: %v", err)
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(yaml)), nil
+}
+
+func (lock *Lock) Preview(directory string) (bool, error) {
+	lock.printSuggestions()
+
+	original, err := ReadLock(directory)
+	if err != nil {
+		return lock.dirty, err
 	}
+
+	DiffLocks(original, lock).Print()
+
+	return lock.dirty, nil
 }
 
 func (lock *Lock) Save(directory string) (bool, error) {
 	lock.printSuggestions()
 
+	name := lock.Format().Name()
 	if !lock.dirty {
-		log.Printf("yarn.lock stable")
+		log.Printf("%s stable", name)
 		return false, nil
 	}
 
-	yaml, err := yaml2.Marshal(lock.resolutions)
+	log.Printf("%s dirty, needs `%s install`", name, lock.Format().Manager().Command())
+	return true, lock.WriteFile(filepath.Join(directory, name))
+}
+
+// Format is the lockfile format the lock was read from and is written in, berry for new locks.
+func (lock *Lock) Format() Lockfile {
+	if lock == nil || lock.format == nil {
+		return BerryLockfile
+	}
+
+	return lock.format
+}
+
+func (lock *Lock) WriteFile(path string) error {
+	data, err := lock.Format().Serialize(lock)
 	if err != nil {
-		return true, fmt.Errorf("cannot serialize yarn.lock: %v", err)
+		return err
 	}
 
-	log.Printf("yarn.lock dirty, needs `yarn install`")
-	return true, ioutil.WriteFile(yarnLock(directory), yaml, fs.ModePerm)
+	return ioutil.WriteFile(path, data, fs.ModePerm)
 }
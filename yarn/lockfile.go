@@ -0,0 +1,110 @@
+package yarn
+
+import (
+	"bytes"
+	"fmt"
+	"gnarl/semver"
+	"os"
+	"path/filepath"
+
+	yaml2 "gopkg.in/yaml.v2"
+)
+
+// Lockfile is a lockfile format, read into and written from the model of a berry lockfile: entries keyed by their
+// descriptors, like lodash@npm:^4.17.0, with the locator they resolve to.
+type Lockfile interface {
+	// Name is the file name of the lockfile in the project directory.
+	Name() string
+	Manager() Manager
+	Parse(data []byte) (*Lock, error)
+	Serialize(lock *Lock) ([]byte, error)
+}
+
+var (
+	BerryLockfile   Lockfile = berryLockfile{}
+	ClassicLockfile Lockfile = classicLockfile{}
+	NpmLockfile     Lockfile = &npmLockfile{}
+	PnpmLockfile    Lockfile = &pnpmLockfile{}
+)
+
+var lockfiles = []Lockfile{BerryLockfile, NpmLockfile, PnpmLockfile}
+
+// DetectLockfile finds the lockfile of the project in the directory, yarn.lock first.
+func DetectLockfile(directory string) (Lockfile, error) {
+	for _, lockfile := range lockfiles {
+		if _, err := os.Stat(filepath.Join(directory, lockfile.Name())); err == nil {
+			return lockfile, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no yarn.lock, package-lock.json or pnpm-lock.yaml found in %s", directory)
+}
+
+// detectFormat tells the lockfile formats apart by their content.
+func detectFormat(data []byte) Lockfile {
+	switch {
+	case bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")):
+		return NpmLockfile
+	case bytes.HasPrefix(bytes.TrimSpace(data), []byte("lockfileVersion:")):
+		return PnpmLockfile
+	case isClassicLock(data):
+		return ClassicLockfile
+	default:
+		return BerryLockfile
+	}
+}
+
+func newLock(format Lockfile) *Lock {
+	return &Lock{resolutions: map[string]Resolution{}, suggestions: map[string]*semver.Version{}, advisories: map[string][]string{}, format: format}
+}
+
+type berryLockfile struct{}
+
+func (berryLockfile) Name() string {
+	return "yarn.lock"
+}
+
+func (berryLockfile) Manager() Manager {
+	return Berry
+}
+
+func (format berryLockfile) Parse(yaml []byte) (*Lock, error) {
+	lock := newLock(format)
+	err := yaml2.Unmarshal(yaml, lock.resolutions)
+	if err != nil {
+		return nil, fmt.Errorf("cannot deserialize yarn.lock: %v", err)
+	}
+
+	if len(lock.resolutions) == 0 {
+		return nil, fmt.Errorf("no entries found in yarn.lock")
+	}
+
+	return lock, nil
+}
+
+func (berryLockfile) Serialize(lock *Lock) ([]byte, error) {
+	yaml, err := yaml2.Marshal(lock.resolutions)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize yarn.lock: %v", err)
+	}
+
+	return yaml, nil
+}
+
+type classicLockfile struct{}
+
+func (classicLockfile) Name() string {
+	return "yarn.lock"
+}
+
+func (classicLockfile) Manager() Manager {
+	return Classic
+}
+
+func (classicLockfile) Parse(data []byte) (*Lock, error) {
+	return parseClassicLock(data)
+}
+
+func (classicLockfile) Serialize(lock *Lock) ([]byte, error) {
+	return serializeClassicLock(lock), nil
+}
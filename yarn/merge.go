@@ -0,0 +1,166 @@
+package yarn
+
+import (
+	"bytes"
+	"fmt"
+	"gnarl/semver"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type MergeConflict struct {
+	Descriptor string
+	Ours       string
+	Theirs     string
+}
+
+func (conflict MergeConflict) String() string {
+	return fmt.Sprintf("%s: ours %s, theirs %s", conflict.Descriptor, conflict.Ours, conflict.Theirs)
+}
+
+func MergeLocks(base, ours, theirs *Lock) (*Lock, []MergeConflict) {
+	baseEntries, ourEntries, theirEntries := base.descriptorEntries(), ours.descriptorEntries(), theirs.descriptorEntries()
+
+	descriptors := make(map[string]bool)
+	for _, entries := range []map[string]Resolution{baseEntries, ourEntries, theirEntries} {
+		for descriptor := range entries {
+			descriptors[descriptor] = true
+		}
+	}
+
+	var conflicts []MergeConflict
+	merged := make(map[string]Resolution)
+	for descriptor := range descriptors {
+		b, inBase := baseEntries[descriptor]
+		o, inOurs := ourEntries[descriptor]
+		t, inTheirs := theirEntries[descriptor]
+
+		switch {
+		case inOurs == inTheirs && reflect.DeepEqual(o, t):
+		case inOurs == inBase && reflect.DeepEqual(o, b):
+			o, inOurs = t, inTheirs
+		case inTheirs == inBase && reflect.DeepEqual(t, b):
+		case !inOurs:
+			o, inOurs = t, true
+		case !inTheirs:
+		default:
+			resolved, ok := pickHigher(o, t)
+			if !ok {
+				conflicts = append(conflicts, MergeConflict{Descriptor: descriptor, Ours: o.Resolution, Theirs: t.Resolution})
+			}
+
+			o = resolved
+		}
+
+		if inOurs {
+			merged[descriptor] = o
+		}
+	}
+
+	lock := newLock(ours.Format())
+	lock.dirty = true
+	conflicts = append(conflicts, lock.group(merged)...)
+
+	sort.Slice(conflicts, func(p, q int) bool { return conflicts[p].Descriptor < conflicts[q].Descriptor })
+
+	return lock, conflicts
+}
+
+func (lock *Lock) descriptorEntries() map[string]Resolution {
+	entries := make(map[string]Resolution)
+	if lock == nil {
+		return entries
+	}
+
+	for descriptor, key := range lock.descriptors() {
+		entries[descriptor] = lock.resolutions[key]
+	}
+
+	return entries
+}
+
+// pickHigher prefers the higher version when both sides changed a descriptor; equal versions with different content conflict.
+func pickHigher(ours, theirs Resolution) (Resolution, bool) {
+	o, errOurs := semver.ParseVersion(ours.Version)
+	t, errTheirs := semver.ParseVersion(theirs.Version)
+	if errOurs != nil || errTheirs != nil {
+		return ours, false
+	}
+
+	switch c := o.Compare(t); {
+	case c > 0:
+		return ours, true
+	case c < 0:
+		return theirs, true
+	default:
+		return ours, false
+	}
+}
+
+// group joins the descriptors locked to the same package into a single entry.
+func (lock *Lock) group(entries map[string]Resolution) []MergeConflict {
+	groups := make(map[string][]string)
+	for descriptor, resolution := range entries {
+		identity := resolution.Resolution
+		if identity == "" {
+			identity = descriptor
+		}
+
+		groups[identity] = append(groups[identity], descriptor)
+	}
+
+	var conflicts []MergeConflict
+	for _, descriptors := range groups {
+		sort.Strings(descriptors)
+		resolution := entries[descriptors[0]]
+		for _, descriptor := range descriptors[1:] {
+			if !reflect.DeepEqual(entries[descriptor], resolution) {
+				conflicts = append(conflicts, MergeConflict{Descriptor: descriptor, Ours: resolution.Resolution, Theirs: entries[descriptor].Resolution})
+			}
+		}
+
+		lock.resolutions[strings.Join(descriptors, ", ")] = resolution
+	}
+
+	return conflicts
+}
+
+// SplitConflict separates a lockfile with git conflict markers into the base, our and their versions.
+func SplitConflict(data []byte) ([]byte, []byte, []byte, bool) {
+	var base, ours, theirs bytes.Buffer
+	side := 0
+	conflicted := false
+
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		switch {
+		case bytes.HasPrefix(line, []byte("<<<<<<<")):
+			side, conflicted = 1, true
+			continue
+		case bytes.HasPrefix(line, []byte("|||||||")) && side == 1:
+			side = 2
+			continue
+		case bytes.HasPrefix(line, []byte("=======")) && side > 0:
+			side = 3
+			continue
+		case bytes.HasPrefix(line, []byte(">>>>>>>")) && side == 3:
+			side = 0
+			continue
+		}
+
+		switch side {
+		case 0:
+			base.Write(line)
+			ours.Write(line)
+			theirs.Write(line)
+		case 1:
+			ours.Write(line)
+		case 2:
+			base.Write(line)
+		case 3:
+			theirs.Write(line)
+		}
+	}
+
+	return base.Bytes(), ours.Bytes(), theirs.Bytes(), conflicted
+}
@@ -0,0 +1,70 @@
+package yarn_test
+
+import (
+	"gnarl/yarn"
+	"strings"
+	"testing"
+)
+
+const extraEntry = `
+"right@npm:^2.0.0":
+  version: 2.0.0
+  resolution: "right@npm:2.0.0"
+  languageName: node
+  linkType: hard
+`
+
+func mustParseLock(t *testing.T, lock string) *yarn.Lock {
+	parsed, err := yarn.ParseLock([]byte(lock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return parsed
+}
+
+func TestMergeLocks(t *testing.T) {
+	base := mustParseLock(t, testLock)
+	ours := mustParseLock(t, strings.NewReplacer("4.17.20", "4.17.21", "@scope/left@npm:1.2.0", "@scope/left@npm:1.3.0", "version: 1.2.0", "version: 1.3.0").Replace(testLock))
+	theirs := mustParseLock(t, strings.NewReplacer("@scope/left@npm:1.2.0", "@scope/left@npm:1.2.5", "version: 1.2.0", "version: 1.2.5").Replace(testLock)+extraEntry)
+
+	merged, conflicts := yarn.MergeLocks(base, ours, theirs)
+	if len(conflicts) != 0 {
+		t.Errorf("Unexpected conflicts %v", conflicts)
+	}
+
+	versions := make(map[string]string)
+	for _, locked := range merged.Packages() {
+		versions[locked.Name] = locked.Version.String()
+	}
+
+	for name, expected := range map[string]string{"lodash": "4.17.21", "@scope/left": "1.3.0", "right": "2.0.0"} {
+		if versions[name] != expected {
+			t.Errorf("Expected %s@%s, got %v", name, expected, versions)
+		}
+	}
+}
+
+func TestMergeLocksConflict(t *testing.T) {
+	base := mustParseLock(t, testLock)
+	ours := mustParseLock(t, strings.Replace(testLock, "checksum: def", "checksum: ours", 1))
+	theirs := mustParseLock(t, strings.Replace(testLock, "checksum: def", "checksum: theirs", 1))
+
+	_, conflicts := yarn.MergeLocks(base, ours, theirs)
+	if len(conflicts) != 2 || !strings.HasPrefix(conflicts[0].Descriptor, "lodash@npm:") {
+		t.Errorf("Expected conflicts on both lodash descriptors, got %v", conflicts)
+	}
+}
+
+func TestSplitConflict(t *testing.T) {
+	conflicted := strings.Replace(testLock, "  checksum: def\n", "<<<<<<< ours\n  checksum: ours\n||||||| base\n  checksum: def\n=======\n  checksum: theirs\n>>>>>>> theirs\n", 1)
+
+	base, ours, theirs, ok := yarn.SplitConflict([]byte(conflicted))
+	if !ok {
+		t.Fatal("Expected conflict markers to be found")
+	}
+
+	if string(base) != testLock || !strings.Contains(string(ours), "checksum: ours") || !strings.Contains(string(theirs), "checksum: theirs") {
+		t.Errorf("Unexpected split:\n%s\n%s\n%s", base, ours, theirs)
+	}
+}
@@ -0,0 +1,611 @@
+package yarn
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// npmLockfile reads package-lock.json lockfileVersion 2 and 3. The entries are keyed by their install path, like
+// node_modules/a/node_modules/b, so the descriptors are found by resolving the dependencies like node does. Writing
+// patches the original document: removed entries are left for npm install to resolve again. Locks read from other
+// formats are written as a new lockfileVersion 3 document.
+type npmLockfile struct {
+	original []byte
+	paths    map[string]npmInstall
+}
+
+type npmInstall struct {
+	locator     string
+	descriptors []string
+	workspace   bool
+}
+
+type npmDocument struct {
+	Name            string              `json:"name"`
+	LockfileVersion int                 `json:"lockfileVersion"`
+	Packages        map[string]npmEntry `json:"packages"`
+}
+
+type npmEntry struct {
+	Name                 string            `json:"name,omitempty"`
+	Version              string            `json:"version,omitempty"`
+	Resolved             string            `json:"resolved,omitempty"`
+	Integrity            string            `json:"integrity,omitempty"`
+	Link                 bool              `json:"link,omitempty"`
+	Dependencies         map[string]string `json:"dependencies,omitempty"`
+	DevDependencies      map[string]string `json:"devDependencies,omitempty"`
+	OptionalDependencies map[string]string `json:"optionalDependencies,omitempty"`
+	PeerDependencies     map[string]string `json:"peerDependencies,omitempty"`
+	Bin                  map[string]string `json:"bin,omitempty"`
+	License              json.RawMessage   `json:"license,omitempty"`
+}
+
+func (*npmLockfile) Name() string {
+	return "package-lock.json"
+}
+
+func (*npmLockfile) Manager() Manager {
+	return Npm
+}
+
+func (*npmLockfile) Parse(data []byte) (*Lock, error) {
+	var document npmDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("cannot deserialize package-lock.json: %v", err)
+	}
+
+	if document.LockfileVersion < 2 {
+		return nil, fmt.Errorf("package-lock.json lockfileVersion %d is not supported, run npm install with npm 7 or later", document.LockfileVersion)
+	}
+
+	if len(document.Packages) == 0 {
+		return nil, fmt.Errorf("no entries found in package-lock.json")
+	}
+
+	format := &npmLockfile{original: data, paths: make(map[string]npmInstall)}
+	lock := newLock(format)
+
+	for path, entry := range document.Packages {
+		if !entry.Link {
+			format.paths[path] = npmInstall{locator: document.locator(path, entry), workspace: !strings.Contains(path, "node_modules/")}
+		}
+	}
+
+	for path, entry := range document.Packages {
+		install, ok := format.paths[path]
+		if !ok {
+			continue
+		}
+
+		sections := []map[string]string{entry.Dependencies, entry.OptionalDependencies}
+		if install.workspace {
+			sections = append(sections, entry.DevDependencies)
+		}
+
+		for _, dependencies := range sections {
+			for name, request := range dependencies {
+				target, ok := document.resolve(path, name)
+				if !ok {
+					continue
+				}
+
+				installed := format.paths[target]
+				installed.descriptors = append(installed.descriptors, npmDescriptor(name, request))
+				format.paths[target] = installed
+			}
+		}
+	}
+
+	var paths []string
+	for path := range format.paths {
+		paths = append(paths, path)
+	}
+
+	sort.Slice(paths, func(p, q int) bool {
+		if depth := strings.Count(paths[p], "node_modules/") - strings.Count(paths[q], "node_modules/"); depth != 0 {
+			return depth < 0
+		}
+
+		return paths[p] < paths[q]
+	})
+
+	// A range can be installed at different versions in different places, the deeper ones get an exact descriptor.
+	entries := make(map[string]Resolution)
+	for _, path := range paths {
+		install := format.paths[path]
+		resolution := document.Packages[path].resolution(install.locator)
+		name, reference := splitLocator(install.locator)
+
+		descriptors := make(map[string]bool)
+		if install.workspace {
+			descriptors[install.locator] = true
+		}
+
+		for _, descriptor := range install.descriptors {
+			if existing, ok := entries[descriptor]; ok && existing.Resolution != install.locator {
+				descriptor = name + "@" + reference
+			}
+
+			descriptors[descriptor] = true
+		}
+
+		if len(descriptors) == 0 {
+			descriptors[name+"@"+reference] = true
+		}
+
+		install.descriptors = nil
+		for descriptor := range descriptors {
+			install.descriptors = append(install.descriptors, descriptor)
+			entries[descriptor] = resolution
+		}
+
+		sort.Strings(install.descriptors)
+		format.paths[path] = install
+	}
+
+	lock.group(entries)
+	return lock, nil
+}
+
+// locator identifies the package installed at the path, workspaces by their directory.
+func (document *npmDocument) locator(path string, entry npmEntry) string {
+	name := entry.Name
+	switch {
+	case path == "":
+		if name == "" {
+			name = document.Name
+		}
+
+		if name == "" {
+			name = "root-workspace"
+		}
+
+		return name + "@workspace:."
+	case !strings.Contains(path, "node_modules/"):
+		if name == "" {
+			name = path[strings.LastIndex(path, "/")+1:]
+		}
+
+		return name + "@workspace:" + path
+	}
+
+	if name == "" {
+		name = npmName(path)
+	}
+
+	if entry.Resolved == "" || strings.HasPrefix(entry.Resolved, "http://") || strings.HasPrefix(entry.Resolved, "https://") {
+		return fmt.Sprintf("%s@npm:%s", name, entry.Version)
+	}
+
+	return name + "@" + entry.Resolved
+}
+
+// resolve finds the path a dependency of the package at the path is installed at, looking in the node_modules of the
+// package and then in those of its ancestors. Links resolve to the directory they point to.
+func (document *npmDocument) resolve(path string, name string) (string, bool) {
+	for directory := path; ; directory = npmParent(directory) {
+		candidate := "node_modules/" + name
+		if directory != "" {
+			candidate = directory + "/" + candidate
+		}
+
+		if entry, ok := document.Packages[candidate]; ok {
+			if entry.Link {
+				_, ok := document.Packages[entry.Resolved]
+				return entry.Resolved, ok
+			}
+
+			return candidate, true
+		}
+
+		if directory == "" {
+			return "", false
+		}
+	}
+}
+
+func npmParent(path string) string {
+	if loc := strings.LastIndex(path, "/node_modules/"); loc >= 0 {
+		return path[:loc]
+	}
+
+	return ""
+}
+
+// npmName is the package name at the end of an install path, like @scope/name.
+func npmName(path string) string {
+	return path[strings.LastIndex(path, "node_modules/")+len("node_modules/"):]
+}
+
+func npmDescriptor(name string, request string) string {
+	if !strings.Contains(request, ":") {
+		request = "npm:" + request
+	}
+
+	return name + "@" + request
+}
+
+func (entry npmEntry) resolution(locator string) Resolution {
+	resolution := Resolution{
+		Version:          entry.Version,
+		Resolution:       locator,
+		Resolved:         entry.Resolved,
+		Integrity:        entry.Integrity,
+		PeerDependencies: entry.PeerDependencies,
+		Bin:              entry.Bin,
+		License:          Manifest{License: entry.License}.license(),
+	}
+
+	if _, reference := splitLocator(locator); strings.HasPrefix(reference, "workspace:") {
+		resolution.Version = "0.0.0-use.local"
+		resolution.Resolved, resolution.Integrity = "", ""
+		for dependency, request := range entry.DevDependencies {
+			resolution.addClassicDependency("dependencies", dependency, request)
+		}
+	}
+
+	for dependency, request := range entry.Dependencies {
+		resolution.addClassicDependency("dependencies", dependency, request)
+	}
+
+	for dependency, request := range entry.OptionalDependencies {
+		resolution.addClassicDependency("optionalDependencies", dependency, request)
+	}
+
+	return resolution
+}
+
+func (format *npmLockfile) Serialize(lock *Lock) ([]byte, error) {
+	if format.original == nil {
+		return generateNpmLock(lock), nil
+	}
+
+	document, err := parseOrderedObject(format.original)
+	if err != nil {
+		return nil, fmt.Errorf("cannot deserialize package-lock.json: %v", err)
+	}
+
+	packages, err := document.child("packages")
+	if err != nil {
+		return nil, fmt.Errorf("cannot deserialize package-lock.json: %v", err)
+	}
+
+	_, hasLegacy := document.values["dependencies"]
+	descriptors := lock.descriptors()
+
+	var removed []string
+	for _, path := range append([]string{}, packages.keys...) {
+		install, ok := format.paths[path]
+		if !ok || install.workspace {
+			continue
+		}
+
+		current, found := install.current(lock, descriptors)
+		switch {
+		case !found:
+			removed = append(removed, path)
+			packages.delete(path)
+			if err := document.deletePath(npmLegacyPath(path)); err != nil {
+				return nil, fmt.Errorf("cannot remove %s from package-lock.json: %v", path, err)
+			}
+		case current.Resolution != install.locator:
+			entry, err := packages.child(path)
+			if err != nil {
+				return nil, fmt.Errorf("cannot update %s in package-lock.json: %v", path, err)
+			}
+
+			packages.set(path, npmUpdate(entry, current).marshal("", ""))
+			if hasLegacy {
+				if err := document.updateLegacy(npmLegacyPath(path), current); err != nil {
+					return nil, fmt.Errorf("cannot update %s in package-lock.json: %v", path, err)
+				}
+			}
+		}
+	}
+
+	for _, path := range append([]string{}, packages.keys...) {
+		for _, parent := range removed {
+			if strings.HasPrefix(path, parent+"/node_modules/") {
+				packages.delete(path)
+			}
+		}
+	}
+
+	document.set("packages", packages.marshal("", ""))
+	if _, ok := document.values["dependencies"]; hasLegacy && !ok {
+		document.set("dependencies", json.RawMessage("{}"))
+	}
+
+	return append(document.marshal("", detectIndent(format.original)), '\n'), nil
+}
+
+// current finds the lock entry the installed package is now locked to, if it is still locked.
+func (install npmInstall) current(lock *Lock, descriptors map[string]string) (Resolution, bool) {
+	var found []Resolution
+	for _, descriptor := range install.descriptors {
+		if key, ok := descriptors[descriptor]; ok {
+			if lock.resolutions[key].Resolution == install.locator {
+				return lock.resolutions[key], true
+			}
+
+			found = append(found, lock.resolutions[key])
+		}
+	}
+
+	if len(found) == 0 {
+		return Resolution{}, false
+	}
+
+	return found[0], true
+}
+
+// npmUpdate rewrites the version, tarball and dependencies of an entry for a new version. Other fields, like the name,
+// flags like dev and optional, engines, license and hasInstallScript, are kept in their place.
+func npmUpdate(entry *orderedObject, resolution Resolution) *orderedObject {
+	setString := func(key string, value string) {
+		if value == "" {
+			entry.delete(key)
+			return
+		}
+
+		raw, _ := npmJSON(value)
+		entry.set(key, raw)
+	}
+
+	setString("version", resolution.Version)
+	setString("resolved", resolution.Resolved)
+	setString("integrity", resolution.Integrity)
+
+	dependencies, optional := make(map[string]string), make(map[string]string)
+	for name, request := range resolution.Dependencies {
+		if resolution.DependenciesMeta[name].Optional {
+			optional[name] = classicRange(request)
+		} else {
+			dependencies[name] = classicRange(request)
+		}
+	}
+
+	for _, section := range []struct {
+		key   string
+		value map[string]string
+	}{{"dependencies", dependencies}, {"optionalDependencies", optional}, {"peerDependencies", resolution.PeerDependencies}, {"bin", resolution.Bin}} {
+		if len(section.value) == 0 {
+			entry.delete(section.key)
+			continue
+		}
+
+		raw, _ := npmJSON(section.value)
+		entry.set(section.key, raw)
+	}
+
+	return entry
+}
+
+// npmLegacyPath is the path of an install path in the nested dependencies of lockfileVersion 2.
+func npmLegacyPath(path string) []string {
+	var legacy []string
+	for _, name := range strings.Split(strings.TrimPrefix(path, "node_modules/"), "/node_modules/") {
+		legacy = append(legacy, "dependencies", name)
+	}
+
+	return legacy
+}
+
+func (document *orderedObject) updateLegacy(path []string, resolution Resolution) error {
+	for _, field := range []struct{ key, value string }{{"version", resolution.Version}, {"resolved", resolution.Resolved}, {"integrity", resolution.Integrity}} {
+		fieldPath := append(append([]string{}, path...), field.key)
+		if field.value == "" {
+			if err := document.deletePath(fieldPath); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		raw, _ := npmJSON(field.value)
+		if err := document.setPath(fieldPath, raw); err != nil {
+			return err
+		}
+	}
+
+	requires := make(map[string]string)
+	for name, request := range resolution.Dependencies {
+		requires[name] = classicRange(request)
+	}
+
+	if len(requires) == 0 {
+		return document.deletePath(append(append([]string{}, path...), "requires"))
+	}
+
+	raw, _ := npmJSON(requires)
+	return document.setPath(append(append([]string{}, path...), "requires"), raw)
+}
+
+// npmEdge is a dependency of the package installed at from, found at location.
+type npmEdge struct {
+	from     string
+	location string
+	key      string
+}
+
+// generateNpmLock installs the lock into a node_modules tree like npm does: breadth first from the workspaces, each
+// package as high as it goes without shadowing a different version another package already resolved.
+func generateNpmLock(lock *Lock) []byte {
+	descriptors := lock.descriptors()
+	installed := make(map[string]string)
+	links := make(map[string]string)
+	edges := make(map[string][]npmEdge)
+
+	var workspaces []string
+	directories := make(map[string]string)
+	for key, resolution := range lock.resolutions {
+		if _, reference := splitLocator(resolution.Resolution); strings.HasPrefix(reference, "workspace:") {
+			directories[key] = strings.TrimPrefix(strings.TrimPrefix(reference, "workspace:"), ".")
+			workspaces = append(workspaces, key)
+		}
+	}
+
+	sort.Slice(workspaces, func(p, q int) bool { return directories[workspaces[p]] < directories[workspaces[q]] })
+
+	var queue []string
+	for _, key := range workspaces {
+		directory := directories[key]
+		installed[directory] = key
+		queue = append(queue, directory)
+		if directory != "" {
+			name, _ := splitLocator(lock.resolutions[key].Resolution)
+			installed["node_modules/"+name], links["node_modules/"+name] = key, directory
+		}
+	}
+
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+
+		resolution := lock.resolutions[installed[path]]
+		var names []string
+		for name := range resolution.Dependencies {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+		for _, name := range names {
+			key, ok := resolveDependency(descriptors, name, resolution.Dependencies[name])
+			if !ok {
+				continue
+			}
+
+			location, placed := npmPlace(installed, edges[name], path, name, key)
+			edges[name] = append(edges[name], npmEdge{from: path, location: location, key: key})
+			if !placed {
+				installed[location] = key
+				queue = append(queue, location)
+			}
+		}
+	}
+
+	var paths []string
+	for path := range installed {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	packages := &orderedObject{values: map[string]json.RawMessage{}}
+	for _, path := range paths {
+		resolution := lock.resolutions[installed[path]]
+		name, _ := splitLocator(resolution.Resolution)
+		entry := &orderedObject{values: map[string]json.RawMessage{}}
+		switch _, workspace := directories[installed[path]]; {
+		case links[path] != "":
+			raw, _ := npmJSON(links[path])
+			entry.set("resolved", raw)
+			entry.set("link", json.RawMessage("true"))
+		case workspace:
+			raw, _ := npmJSON(name)
+			entry.set("name", raw)
+			if path == "" && len(workspaces) > 1 {
+				var directories []string
+				for _, candidate := range paths {
+					if candidate != "" && !strings.Contains(candidate, "node_modules/") {
+						directories = append(directories, candidate)
+					}
+				}
+
+				raw, _ := npmJSON(directories)
+				entry.set("workspaces", raw)
+			}
+
+			resolution.Version = ""
+			updated := npmUpdate(entry, resolution)
+			for _, key := range updated.keys {
+				entry.set(key, updated.values[key])
+			}
+		default:
+			if name != npmName(path) {
+				raw, _ := npmJSON(name)
+				entry.set("name", raw)
+			}
+
+			entry = npmUpdate(entry, resolution)
+		}
+
+		packages.set(path, entry.marshal("", ""))
+	}
+
+	document := &orderedObject{values: map[string]json.RawMessage{}}
+	root, _ := splitLocator(lock.resolutions[installed[""]].Resolution)
+	for _, field := range []struct {
+		key   string
+		value interface{}
+	}{{"name", root}, {"lockfileVersion", 3}, {"requires", true}} {
+		raw, _ := npmJSON(field.value)
+		document.set(field.key, raw)
+	}
+
+	document.set("packages", packages.marshal("", ""))
+	return append(document.marshal("", "  "), '\n')
+}
+
+// npmPlace finds where a dependency of the package at path goes, climbing while nothing is in the way. The second
+// result tells whether it is already installed there.
+func npmPlace(installed map[string]string, edges []npmEdge, path string, name string, key string) (string, bool) {
+	location := ""
+	for directory := path; ; directory = npmParent(directory) {
+		candidate := "node_modules/" + name
+		if directory != "" {
+			candidate = directory + "/" + candidate
+		}
+
+		if existing, ok := installed[candidate]; ok {
+			if existing == key {
+				return candidate, true
+			}
+
+			break
+		}
+
+		if npmShadows(edges, directory, key) {
+			break
+		}
+
+		location = candidate
+		if directory == "" {
+			break
+		}
+	}
+
+	return location, false
+}
+
+// npmShadows tells whether installing a package in the node_modules of the directory hides a different version from
+// packages below it that resolved it higher up.
+func npmShadows(edges []npmEdge, directory string, key string) bool {
+	for _, edge := range edges {
+		above := npmParent(edge.location)
+		if edge.key != key && npmWithin(edge.from, directory) && above != directory && npmWithin(directory, above) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func npmWithin(path string, directory string) bool {
+	return directory == "" || path == directory || strings.HasPrefix(path, directory+"/")
+}
+
+// npmJSON marshals like npm does, leaving <, > and & as they are.
+func npmJSON(value interface{}) (json.RawMessage, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,161 @@
+package yarn_test
+
+import (
+	"gnarl/yarn"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const npmLock = `
+{
+  "name": "app",
+  "version": "1.0.0",
+  "lockfileVersion": 3,
+  "requires": true,
+  "packages": {
+    "": {
+      "name": "app",
+      "version": "1.0.0",
+      "workspaces": [
+        "packages/*"
+      ],
+      "dependencies": {
+        "left": "^1.0.0",
+        "lodash": "^4.17.20"
+      }
+    },
+    "node_modules/left": {
+      "version": "1.2.0",
+      "resolved": "https://registry.npmjs.org/left/-/left-1.2.0.tgz",
+      "integrity": "sha512-left==",
+      "dependencies": {
+        "lodash": "^3.0.0"
+      }
+    },
+    "node_modules/left/node_modules/lodash": {
+      "version": "3.10.1",
+      "resolved": "https://registry.npmjs.org/lodash/-/lodash-3.10.1.tgz",
+      "integrity": "sha512-lodash3=="
+    },
+    "node_modules/lodash": {
+      "version": "4.17.20",
+      "resolved": "https://registry.npmjs.org/lodash/-/lodash-4.17.20.tgz",
+      "integrity": "sha512-lodash4==",
+      "license": "MIT"
+    },
+    "node_modules/tool": {
+      "resolved": "packages/tool",
+      "link": true
+    },
+    "packages/tool": {
+      "name": "tool",
+      "version": "0.1.0",
+      "dependencies": {
+        "lodash": "^4.17.0"
+      }
+    }
+  }
+}
+`
+
+func mustWriteNpmLock(t *testing.T, lock *yarn.Lock) string {
+	file := filepath.Join(t.TempDir(), "package-lock.json")
+	if err := lock.WriteFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(written)
+}
+
+func TestNpmLock(t *testing.T) {
+	lock := mustParseLock(t, npmLock)
+	if lock.Manager() != yarn.Npm {
+		t.Fatalf("expected an npm lockfile, got %s", lock.Manager())
+	}
+
+	if actual := lockedLocators(lock); actual != "left@1.2.0 lodash@3.10.1 lodash@4.17.20" {
+		t.Errorf("unexpected packages %s", actual)
+	}
+
+	pattern, err := yarn.ParseResetPattern("lodash@^3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chains, _ := lock.Why(pattern, 10)
+	if len(chains) != 1 || strings.Join(chains[0], " > ") != "app@workspace:. > left@npm:1.2.0 > lodash@npm:3.10.1" {
+		t.Errorf("unexpected chains %v", chains)
+	}
+
+	if written := mustWriteNpmLock(t, lock); written != strings.TrimPrefix(npmLock, "\n") {
+		t.Errorf("expected an unchanged package-lock.json, got\n%s", written)
+	}
+}
+
+func TestNpmLockReset(t *testing.T) {
+	lock := mustParseLock(t, npmLock)
+	pattern, err := yarn.ParseResetPattern("left")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock.ResetMatching(pattern, false)
+	written := mustWriteNpmLock(t, lock)
+	if strings.Contains(written, "node_modules/left") {
+		t.Errorf("expected left and its nested dependencies to be removed, got\n%s", written)
+	}
+
+	if actual := lockedLocators(mustParseLock(t, written)); actual != "lodash@4.17.20" {
+		t.Errorf("unexpected packages %s", actual)
+	}
+}
+
+func TestNpmLockUpgrade(t *testing.T) {
+	lock := mustParseLock(t, strings.Replace(npmLock, `"license": "MIT"`, `"license": "MIT",
+      "hasInstallScript": true,
+      "engines": {
+        "node": ">=4"
+      }`, 1))
+	metadata := fakeMetadata{
+		"lodash": {Versions: map[string]yarn.Manifest{
+			"3.10.1":  {Version: "3.10.1"},
+			"4.17.20": {Version: "4.17.20"},
+			"4.17.21": {Version: "4.17.21", Dist: yarn.Dist{Tarball: "https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz", Integrity: "sha512-new=="}},
+		}},
+	}
+
+	pattern, err := yarn.ParseResetPattern("lodash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lock.Upgrade(pattern, metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	written := mustWriteNpmLock(t, lock)
+	expected := `"node_modules/lodash": {
+      "version": "4.17.21",
+      "resolved": "https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz",
+      "integrity": "sha512-new==",
+      "license": "MIT",
+      "hasInstallScript": true,
+      "engines": {
+        "node": ">=4"
+      }
+    }`
+	if !strings.Contains(written, expected) {
+		t.Errorf("expected lodash to be rewritten for 4.17.21 keeping its other fields, got\n%s", written)
+	}
+
+	if actual := lockedLocators(mustParseLock(t, written)); actual != "left@1.2.0 lodash@3.10.1 lodash@4.17.21" {
+		t.Errorf("unexpected packages %s", actual)
+	}
+}
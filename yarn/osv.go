@@ -0,0 +1,258 @@
+package yarn
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gnarl/semver"
+	"log"
+	"strings"
+)
+
+type Osv struct {
+	Id               string        `json:"id"`
+	Aliases          []string      `json:"aliases,omitempty"`
+	Summary          string        `json:"summary,omitempty"`
+	Affected         []OsvAffected `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity,omitempty"`
+	} `json:"database_specific,omitempty"`
+}
+
+type OsvAffected struct {
+	Package  OsvPackage `json:"package"`
+	Ranges   []OsvRange `json:"ranges,omitempty"`
+	Versions []string   `json:"versions,omitempty"`
+}
+
+type OsvPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+type OsvRange struct {
+	Type   string     `json:"type"`
+	Events []OsvEvent `json:"events"`
+}
+
+type OsvEvent struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+type Ghsa struct {
+	GhsaId          string              `json:"ghsa_id"`
+	Summary         string              `json:"summary,omitempty"`
+	Severity        string              `json:"severity,omitempty"`
+	HtmlUrl         string              `json:"html_url,omitempty"`
+	Vulnerabilities []GhsaVulnerability `json:"vulnerabilities"`
+}
+
+// GhsaVulnerability is the vulnerable range of one package of a GitHub advisory.
+type GhsaVulnerability struct {
+	Package                OsvPackage `json:"package"`
+	VulnerableVersionRange string     `json:"vulnerable_version_range"`
+}
+
+func ParseAdvisories(data []byte) ([]Advisory, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '[' {
+		return parseAdvisoryObject(data)
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("cannot deserialize advisories: %v", err)
+	}
+
+	var advisories []Advisory
+	for _, item := range items {
+		parsed, err := parseAdvisoryObject(item)
+		if err != nil {
+			return nil, err
+		}
+
+		advisories = append(advisories, parsed...)
+	}
+
+	return advisories, nil
+}
+
+func parseAdvisoryObject(data []byte) ([]Advisory, error) {
+	var probe struct {
+		Affected        json.RawMessage `json:"affected"`
+		Vulnerabilities json.RawMessage `json:"vulnerabilities"`
+		Advisories      json.RawMessage `json:"advisories"`
+	}
+
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("cannot deserialize advisory: %v", err)
+	}
+
+	switch {
+	case probe.Affected != nil:
+		return ParseOsv(data)
+	case probe.Vulnerabilities != nil:
+		return ParseGhsa(data)
+	case probe.Advisories != nil:
+		return ParseAuditYarn2(data)
+	}
+
+	var advisory Advisory
+	if err := json.Unmarshal(data, &advisory); err != nil {
+		return nil, fmt.Errorf("cannot deserialize advisory: %v", err)
+	}
+
+	return []Advisory{advisory}, nil
+}
+
+func ParseOsv(data []byte) ([]Advisory, error) {
+	var osv Osv
+	if err := json.Unmarshal(data, &osv); err != nil {
+		return nil, fmt.Errorf("cannot deserialize osv json: %v", err)
+	}
+
+	return osv.ToAdvisories()
+}
+
+func (osv Osv) ToAdvisories() ([]Advisory, error) {
+	var advisories []Advisory
+
+	for _, affected := range osv.Affected {
+		if !strings.EqualFold(affected.Package.Ecosystem, "npm") {
+			continue
+		}
+
+		vulnerable, err := affected.vulnerableVersions()
+		if err != nil {
+			log.Printf("skipping advisory %s for %s: %v", osv.Id, affected.Package.Name, err)
+			continue
+		}
+
+		if vulnerable == nil {
+			continue
+		}
+
+		advisories = append(advisories, Advisory{
+			GithubAdvisoryId:   osv.githubAdvisoryId(),
+			Title:              osv.Summary,
+			Severity:           strings.ToLower(osv.DatabaseSpecific.Severity),
+			Url:                fmt.Sprintf("https://osv.dev/vulnerability/%s", osv.Id),
+			ModuleName:         affected.Package.Name,
+			VulnerableVersions: vulnerable.String(),
+			PatchedVersions:    vulnerable.Patches().String(),
+		})
+	}
+
+	return advisories, nil
+}
+
+func (osv Osv) githubAdvisoryId() string {
+	for _, id := range append([]string{osv.Id}, osv.Aliases...) {
+		if strings.HasPrefix(id, "GHSA-") {
+			return id
+		}
+	}
+
+	return osv.Id
+}
+
+func (affected OsvAffected) vulnerableVersions() (*semver.Request, error) {
+	var terms []string
+
+	for _, r := range affected.Ranges {
+		if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+			continue
+		}
+
+		introduced := ""
+		open := false
+		for _, event := range r.Events {
+			switch {
+			case event.Introduced != "":
+				introduced, open = event.Introduced, true
+			case event.Fixed != "" && open:
+				terms = append(terms, osvTerm(introduced, "<"+event.Fixed))
+				open = false
+			case event.LastAffected != "" && open:
+				terms = append(terms, osvTerm(introduced, "<="+event.LastAffected))
+				open = false
+			case event.Limit != "" && open:
+				terms = append(terms, osvTerm(introduced, "<"+event.Limit))
+				open = false
+			}
+		}
+
+		if open {
+			terms = append(terms, osvTerm(introduced, ""))
+		}
+	}
+
+	if len(terms) == 0 {
+		for _, version := range affected.Versions {
+			terms = append(terms, "="+version)
+		}
+	}
+
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	return semver.ParseRequest(strings.Join(terms, " || "))
+}
+
+func osvTerm(introduced string, upper string) string {
+	var factors []string
+	if introduced != "" && introduced != "0" {
+		factors = append(factors, ">="+introduced)
+	}
+
+	if upper != "" {
+		factors = append(factors, upper)
+	}
+
+	if len(factors) == 0 {
+		return "*"
+	}
+
+	return strings.Join(factors, " ")
+}
+
+func ParseGhsa(data []byte) ([]Advisory, error) {
+	var ghsa Ghsa
+	if err := json.Unmarshal(data, &ghsa); err != nil {
+		return nil, fmt.Errorf("cannot deserialize ghsa json: %v", err)
+	}
+
+	return ghsa.ToAdvisories()
+}
+
+func (ghsa Ghsa) ToAdvisories() ([]Advisory, error) {
+	var advisories []Advisory
+
+	for _, vulnerability := range ghsa.Vulnerabilities {
+		if !strings.EqualFold(vulnerability.Package.Ecosystem, "npm") {
+			continue
+		}
+
+		vulnerable, err := semver.ParseRequest(strings.ReplaceAll(vulnerability.VulnerableVersionRange, ",", " "))
+		if err != nil {
+			log.Printf("skipping advisory %s for %s: %v", ghsa.GhsaId, vulnerability.Package.Name, err)
+			continue
+		}
+
+		advisories = append(advisories, Advisory{
+			GithubAdvisoryId:   ghsa.GhsaId,
+			Title:              ghsa.Summary,
+			Severity:           strings.ToLower(ghsa.Severity),
+			Url:                ghsa.HtmlUrl,
+			ModuleName:         vulnerability.Package.Name,
+			VulnerableVersions: vulnerable.String(),
+			PatchedVersions:    vulnerable.Patches().String(),
+		})
+	}
+
+	return advisories, nil
+}
@@ -0,0 +1,83 @@
+package yarn_test
+
+import (
+	"gnarl/yarn"
+	"testing"
+)
+
+func TestParseAdvisoriesOsv(t *testing.T) {
+	advisories, err := yarn.ParseAdvisories([]byte(`{
+		"id": "GHSA-aaaa-bbbb-cccc",
+		"summary": "Prototype pollution",
+		"affected": [{
+			"package": {"ecosystem": "npm", "name": "minimist"},
+			"ranges": [{"type": "SEMVER", "events": [
+				{"introduced": "0"}, {"fixed": "0.2.4"},
+				{"introduced": "1.0.0"}, {"last_affected": "1.2.5"}
+			]}]
+		}, {
+			"package": {"ecosystem": "PyPI", "name": "minimist"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}]}]
+		}],
+		"database_specific": {"severity": "HIGH"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(advisories) != 1 {
+		t.Fatalf("Expected one npm advisory, got %v", advisories)
+	}
+
+	advisory := advisories[0]
+	if advisory.ModuleName != "minimist" || advisory.GithubAdvisoryId != "GHSA-aaaa-bbbb-cccc" || advisory.Severity != "high" {
+		t.Errorf("Unexpected advisory %v", advisory)
+	}
+
+	if advisory.VulnerableVersions != "<0.2.4 || >=1.0.0 <=1.2.5" {
+		t.Errorf("Unexpected vulnerable versions %s", advisory.VulnerableVersions)
+	}
+}
+
+func TestParseAdvisoriesGhsa(t *testing.T) {
+	advisories, err := yarn.ParseAdvisories([]byte(`[{
+		"ghsa_id": "GHSA-dddd-eeee-ffff",
+		"severity": "critical",
+		"vulnerabilities": [
+			{"package": {"ecosystem": "npm", "name": "private-lib"}, "vulnerable_version_range": ">= 2.0.0, < 2.3.1"},
+			{"package": {"ecosystem": "npm", "name": "private-lib"}, "vulnerable_version_range": "< 1.9.9"},
+			{"package": {"ecosystem": "npm", "name": "other-lib"}, "vulnerable_version_range": "< 1.2.0 || >= 1.5.0, < 1.5.3"},
+			{"package": {"ecosystem": "npm", "name": "broken-lib"}, "vulnerable_version_range": "< one"}
+		]
+	}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(advisories) != 3 {
+		t.Fatalf("Expected three advisories, the broken one skipped, got %v", advisories)
+	}
+
+	for i, expected := range []string{"^2.3.1", "^1.9.9", ">=1.2.0 <1.5.0 || ^1.5.3"} {
+		if advisories[i].PatchedVersions != expected {
+			t.Errorf("Expected patched versions %s, got %s", expected, advisories[i].PatchedVersions)
+		}
+	}
+}
+
+func TestParseAdvisoriesSkipsInvalidRanges(t *testing.T) {
+	advisories, err := yarn.ParseAdvisories([]byte(`[{
+		"id": "GHSA-broken",
+		"affected": [{"package": {"ecosystem": "npm", "name": "broken-lib"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "one"}]}]}]
+	}, {
+		"id": "GHSA-fine",
+		"affected": [{"package": {"ecosystem": "npm", "name": "fine-lib"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.0.1"}]}]}]
+	}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(advisories) != 1 || advisories[0].ModuleName != "fine-lib" {
+		t.Errorf("Expected only the advisory with valid ranges, got %v", advisories)
+	}
+}
@@ -0,0 +1,302 @@
+package yarn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Manager is the package manager of a project, which decides the syntax of its overrides.
+type Manager string
+
+const (
+	Berry   Manager = "yarn"
+	Classic Manager = "yarn-classic"
+	Npm     Manager = "npm"
+	Pnpm    Manager = "pnpm"
+)
+
+const (
+	ResolutionsField   = "resolutions"
+	OverridesField     = "overrides"
+	PnpmOverridesField = "pnpm.overrides"
+)
+
+// Override is a resolution, npm override or pnpm override in one model. Parents are the package specs the override
+// is nested in from the outermost, ** meaning any depth.
+type Override struct {
+	Source  string   `json:"source"`
+	Key     string   `json:"key"`
+	Parents []string `json:"parents,omitempty"`
+	Name    string   `json:"name"`
+	Range   string   `json:"range,omitempty"`
+	Value   string   `json:"value"`
+}
+
+// DetectManager uses packageManager in package.json and otherwise the lockfile present.
+func DetectManager(directory string) Manager {
+	if root := findUp(directory, "package.json"); root != "" {
+		if project, err := ReadPackage(root); err == nil && project.PackageManager != "" {
+			name, reference := splitLocator(project.PackageManager)
+			switch {
+			case name == "npm":
+				return Npm
+			case name == "pnpm":
+				return Pnpm
+			case name == "yarn" && strings.HasPrefix(reference, "1."):
+				return Classic
+			case name == "yarn":
+				return Berry
+			}
+		}
+	}
+
+	for _, candidate := range []struct {
+		file    string
+		manager Manager
+	}{{"yarn.lock", Berry}, {"package-lock.json", Npm}, {"pnpm-lock.yaml", Pnpm}} {
+		root := findUp(directory, candidate.file)
+		if root == "" {
+			continue
+		}
+
+		if candidate.manager == Berry {
+			if data, err := ioutil.ReadFile(filepath.Join(root, candidate.file)); err == nil && !strings.Contains(string(data), "__metadata:") {
+				return Classic
+			}
+		}
+
+		return candidate.manager
+	}
+
+	return Berry
+}
+
+// Command is the executable of the manager.
+func (manager Manager) Command() string {
+	if manager == Classic {
+		return "yarn"
+	}
+
+	return string(manager)
+}
+
+// OverrideField is the package.json field holding the overrides of the manager.
+func (manager Manager) OverrideField() string {
+	switch manager {
+	case Npm:
+		return OverridesField
+	case Pnpm:
+		return PnpmOverridesField
+	default:
+		return ResolutionsField
+	}
+}
+
+// OverrideKey is the key overriding the versions of a package requested with a range, yarn classic cannot restrict it
+// to the range.
+func (manager Manager) OverrideKey(name string, rng string) string {
+	if manager == Classic || rng == "" {
+		return "**/" + strings.TrimPrefix(name, "**/")
+	}
+
+	return name + "@" + rng
+}
+
+// Overrides returns the resolutions, npm overrides and pnpm overrides of the project. Values like $name refer to
+// the range of a direct dependency.
+func (project *Package) Overrides() ([]Override, error) {
+	var overrides []Override
+
+	for key, value := range project.Resolutions {
+		parents, name, rng, err := parseOverridePath(key, "/")
+		if err != nil {
+			return nil, err
+		}
+
+		overrides = append(overrides, Override{Source: ResolutionsField, Key: key, Parents: parents, Name: name, Range: rng, Value: value})
+	}
+
+	if len(project.NpmOverrides) > 0 {
+		nested, err := parseNpmOverrides(project.NpmOverrides, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid overrides: %v", err)
+		}
+
+		overrides = append(overrides, nested...)
+	}
+
+	if project.Pnpm != nil {
+		for key, value := range project.Pnpm.Overrides {
+			parents, name, rng, err := parseOverridePath(key, ">")
+			if err != nil {
+				return nil, err
+			}
+
+			overrides = append(overrides, Override{Source: PnpmOverridesField, Key: key, Parents: parents, Name: name, Range: rng, Value: value})
+		}
+	}
+
+	for i := range overrides {
+		if strings.HasPrefix(overrides[i].Value, "$") {
+			reference := project.dependency(overrides[i].Value[1:])
+			if reference == "" {
+				return nil, fmt.Errorf("override %s refers to %s, which is no direct dependency", overrides[i].Key, overrides[i].Value)
+			}
+
+			overrides[i].Value = reference
+		}
+	}
+
+	sort.Slice(overrides, func(p, q int) bool {
+		if overrides[p].Source != overrides[q].Source {
+			return overrides[p].Source < overrides[q].Source
+		}
+
+		return overrides[p].Key < overrides[q].Key
+	})
+
+	return overrides, nil
+}
+
+func (project *Package) dependency(name string) string {
+	for _, dependencies := range []map[string]string{project.Dependencies, project.DevDependencies, project.OptionalDependencies, project.PeerDependencies} {
+		if reference, ok := dependencies[name]; ok {
+			return reference
+		}
+	}
+
+	return ""
+}
+
+// parseOverridePath parses a/b@^1 and a>b@^1 like keys into the parent specs, the name and the range.
+func parseOverridePath(key string, separator string) ([]string, string, string, error) {
+	var specs []string
+	for rest := strings.TrimSpace(key); rest != ""; {
+		var spec string
+		if separator == "/" {
+			spec, rest = splitSpec(rest)
+		} else if loc := strings.Index(rest, separator); loc >= 0 {
+			spec, rest = strings.TrimSpace(rest[:loc]), strings.TrimSpace(rest[loc+len(separator):])
+		} else {
+			spec, rest = rest, ""
+		}
+
+		if spec == "" {
+			return nil, "", "", fmt.Errorf("invalid override %s", key)
+		}
+
+		specs = append(specs, spec)
+	}
+
+	if len(specs) == 0 || specs[len(specs)-1] == "**" {
+		return nil, "", "", fmt.Errorf("invalid override %s", key)
+	}
+
+	name, rng := splitLocator(specs[len(specs)-1])
+	return specs[:len(specs)-1], name, rng, nil
+}
+
+// parseNpmOverrides flattens nested npm overrides, where "." overrides the package of the enclosing object.
+func parseNpmOverrides(raw json.RawMessage, parents []string) ([]Override, error) {
+	object, err := parseOrderedObject(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var overrides []Override
+	for _, key := range object.keys {
+		var value string
+		if err := json.Unmarshal(object.values[key], &value); err == nil {
+			target := append(append([]string{}, parents...), key)
+			if key == "." {
+				if len(parents) == 0 {
+					return nil, fmt.Errorf(`"." outside of a package`)
+				}
+
+				target = parents
+			}
+
+			name, rng := splitLocator(target[len(target)-1])
+			overrides = append(overrides, Override{
+				Source:  OverridesField,
+				Key:     strings.Join(append(append([]string{}, parents...), key), " > "),
+				Parents: target[:len(target)-1],
+				Name:    name,
+				Range:   rng,
+				Value:   value,
+			})
+			continue
+		}
+
+		nested, err := parseNpmOverrides(object.values[key], append(append([]string{}, parents...), key))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+
+		overrides = append(overrides, nested...)
+	}
+
+	return overrides, nil
+}
+
+// ResolutionKey selects the dependencies of the closest parent, deeper ancestors are not taken into account.
+func (override Override) ResolutionKey() *ResolutionKey {
+	key := ResolutionKey{Name: override.Name, Range: override.Range, MatchVersions: override.Source != ResolutionsField && override.Source != ""}
+	for i := len(override.Parents) - 1; i >= 0; i-- {
+		if override.Parents[i] != "**" {
+			key.From, key.FromRange = splitLocator(override.Parents[i])
+			break
+		}
+	}
+
+	return &key
+}
+
+// UpdateOverrides sets and removes overrides in the given field of package.json, keeping the order and indentation
+// of its keys. Keys of npm overrides are nested with " > ".
+func UpdateOverrides(directory string, field string, set map[string]string, remove []string) error {
+	data, err := ioutil.ReadFile(packageJson(directory))
+	if err != nil {
+		return fmt.Errorf("cannot read package.json: %v", err)
+	}
+
+	manifest, err := parseOrderedObject(data)
+	if err != nil {
+		return fmt.Errorf("cannot deserialize package.json: %v", err)
+	}
+
+	path := func(key string) []string {
+		result := strings.Split(field, ".")
+		if field == OverridesField {
+			return append(result, strings.Split(key, " > ")...)
+		}
+
+		return append(result, key)
+	}
+
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, _ := npmJSON(set[key])
+		if err := manifest.setPath(path(key), value); err != nil {
+			return fmt.Errorf("cannot set %s in package.json: %v", key, err)
+		}
+	}
+
+	for _, key := range remove {
+		if err := manifest.deletePath(path(key)); err != nil {
+			return fmt.Errorf("cannot remove %s from package.json: %v", key, err)
+		}
+	}
+
+	return ioutil.WriteFile(packageJson(directory), append(manifest.marshal("", detectIndent(data)), '\n'), os.FileMode(0644))
+}
@@ -1,14 +1,29 @@
 package yarn
 
 import (
+	"bytes"
 	json "encoding/json"
 	"fmt"
+	"gnarl/semver"
 	"io/ioutil"
 	"os"
+	"strings"
 )
 
 type Package struct {
-	Resolutions map[string]string `json:"resolutions,omitempty"`
+	Name                 string            `json:"name,omitempty"`
+	PackageManager       string            `json:"packageManager,omitempty"`
+	Dependencies         map[string]string `json:"dependencies,omitempty"`
+	DevDependencies      map[string]string `json:"devDependencies,omitempty"`
+	OptionalDependencies map[string]string `json:"optionalDependencies,omitempty"`
+	PeerDependencies     map[string]string `json:"peerDependencies,omitempty"`
+	Resolutions          map[string]string `json:"resolutions,omitempty"`
+	NpmOverrides         json.RawMessage   `json:"overrides,omitempty"`
+	Pnpm                 *PnpmSettings     `json:"pnpm,omitempty"`
+}
+
+type PnpmSettings struct {
+	Overrides map[string]string `json:"overrides,omitempty"`
 }
 
 func packageJson(directory string) string {
@@ -36,3 +51,182 @@ func ReadPackage(directory string) (*Package, error) {
 
 	return &packages, nil
 }
+
+// YarnVersion parses packageManager, like yarn@4.1.0+sha224.953c8233.
+func (project *Package) YarnVersion() (*semver.Version, error) {
+	name, reference := splitLocator(project.PackageManager)
+	if name != "yarn" {
+		return nil, fmt.Errorf("packageManager %q is not yarn", project.PackageManager)
+	}
+
+	if loc := strings.Index(reference, "+"); loc >= 0 {
+		reference = reference[:loc]
+	}
+
+	return semver.ParseVersion(reference)
+}
+
+type orderedObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func parseOrderedObject(data []byte) (*orderedObject, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("expected an object")
+	}
+
+	object := orderedObject{values: map[string]json.RawMessage{}}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a key, got %v", token)
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		object.set(key, value)
+	}
+
+	return &object, nil
+}
+
+func (object *orderedObject) set(key string, value json.RawMessage) {
+	if _, ok := object.values[key]; !ok {
+		object.keys = append(object.keys, key)
+	}
+
+	object.values[key] = value
+}
+
+func (object *orderedObject) delete(key string) {
+	if _, ok := object.values[key]; !ok {
+		return
+	}
+
+	delete(object.values, key)
+	for i, candidate := range object.keys {
+		if candidate == key {
+			object.keys = append(object.keys[:i], object.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// setPath sets a value in nested objects, creating them as needed. A string in the way becomes the "." of a new
+// object, like npm overrides do.
+func (object *orderedObject) setPath(path []string, value json.RawMessage) error {
+	if len(path) == 1 {
+		object.set(path[0], value)
+		return nil
+	}
+
+	child, err := object.child(path[0])
+	if err != nil {
+		return err
+	}
+
+	if err := child.setPath(path[1:], value); err != nil {
+		return err
+	}
+
+	object.set(path[0], child.marshal("", ""))
+	return nil
+}
+
+func (object *orderedObject) deletePath(path []string) error {
+	if len(path) == 1 {
+		object.delete(path[0])
+		return nil
+	}
+
+	if _, ok := object.values[path[0]]; !ok {
+		return nil
+	}
+
+	child, err := object.child(path[0])
+	if err != nil {
+		return err
+	}
+
+	if err := child.deletePath(path[1:]); err != nil {
+		return err
+	}
+
+	if len(child.keys) == 0 {
+		object.delete(path[0])
+	} else {
+		object.set(path[0], child.marshal("", ""))
+	}
+
+	return nil
+}
+
+func (object *orderedObject) child(key string) (*orderedObject, error) {
+	raw, ok := object.values[key]
+	if !ok {
+		return &orderedObject{values: map[string]json.RawMessage{}}, nil
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		child := &orderedObject{values: map[string]json.RawMessage{}}
+		child.set(".", raw)
+		return child, nil
+	}
+
+	child, err := parseOrderedObject(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", key, err)
+	}
+
+	return child, nil
+}
+
+func (object *orderedObject) marshal(prefix string, indent string) []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("{")
+	for i, key := range object.keys {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+
+		name, _ := npmJSON(key)
+		buffer.WriteString("\n" + prefix + indent)
+		buffer.Write(name)
+		buffer.WriteString(": ")
+
+		var value bytes.Buffer
+		if err := json.Indent(&value, object.values[key], prefix+indent, indent); err != nil {
+			value.Write(object.values[key])
+		}
+
+		buffer.Write(value.Bytes())
+	}
+
+	if len(object.keys) > 0 {
+		buffer.WriteString("\n" + prefix)
+	}
+
+	buffer.WriteString("}")
+	return buffer.Bytes()
+}
+
+func detectIndent(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		if trimmed := strings.TrimLeft(line, " \t"); trimmed != line && trimmed != "" {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+
+	return "  "
+}
@@ -0,0 +1,72 @@
+package yarn
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestUpdateOverridesKeepsOrder(t *testing.T) {
+	directory := t.TempDir()
+	original := "{\n    \"name\": \"app\",\n    \"resolutions\": {\n        \"b\": \"1.0.0\",\n        \"a\": \"2.0.0\"\n    },\n    \"private\": true\n}\n"
+	if err := ioutil.WriteFile(packageJson(directory), []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateOverrides(directory, ResolutionsField, map[string]string{"c@^1.0.0": "^1.2.0", "a": "2.1.0"}, []string{"b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := ioutil.ReadFile(packageJson(directory))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\n    \"name\": \"app\",\n    \"resolutions\": {\n        \"a\": \"2.1.0\",\n        \"c@^1.0.0\": \"^1.2.0\"\n    },\n    \"private\": true\n}\n"
+	if string(updated) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, updated)
+	}
+}
+
+func TestUpdateNestedOverrides(t *testing.T) {
+	directory := t.TempDir()
+	original := `{"name": "app", "overrides": {"foo": "1.0.0", "baz": {"qux": "2.0.0"}}}`
+	if err := ioutil.WriteFile(packageJson(directory), []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateOverrides(directory, OverridesField, map[string]string{"foo > bar": "^3.0.0"}, []string{"baz > qux"}); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := ioutil.ReadFile(packageJson(directory))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\n  \"name\": \"app\",\n  \"overrides\": {\n    \"foo\": {\n      \".\": \"1.0.0\",\n      \"bar\": \"^3.0.0\"\n    }\n  }\n}\n"
+	if string(updated) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, updated)
+	}
+}
+
+func TestUpdateOverridesKeepsAngleBrackets(t *testing.T) {
+	directory := t.TempDir()
+	original := `{"name": "app", "pnpm": {"overrides": {"foo>bar": "^1.0.0"}}}`
+	if err := ioutil.WriteFile(packageJson(directory), []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateOverrides(directory, PnpmOverridesField, map[string]string{"lodash@<4.17.21": ">=4.17.21 <5"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := ioutil.ReadFile(packageJson(directory))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\n  \"name\": \"app\",\n  \"pnpm\": {\n    \"overrides\": {\n      \"foo>bar\": \"^1.0.0\",\n      \"lodash@<4.17.21\": \">=4.17.21 <5\"\n    }\n  }\n}\n"
+	if string(updated) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, updated)
+	}
+}
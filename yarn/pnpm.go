@@ -0,0 +1,764 @@
+package yarn
+
+import (
+	"bytes"
+	"fmt"
+	"gnarl/semver"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml2 "gopkg.in/yaml.v2"
+)
+
+// pnpmLockfile reads pnpm-lock.yaml lockfileVersion 6 and 9. Importers become workspace entries, and the packages
+// (version 6) or snapshots (version 9) of a package version become one entry, whatever peers they were resolved with.
+// Dependencies are locked to exact versions, so their descriptors are exact too. Writing keeps the original entries
+// of the packages that did not change, so that peer suffixes like 1.0.0(react@18.2.0) survive.
+type pnpmLockfile struct {
+	original *pnpmDocument
+}
+
+type pnpmDocument struct {
+	LockfileVersion      interface{}               `yaml:"lockfileVersion"`
+	Settings             yaml2.MapSlice            `yaml:"settings,omitempty"`
+	Importers            map[string]pnpmImporter   `yaml:"importers,omitempty"`
+	Dependencies         map[string]pnpmDependency `yaml:"dependencies,omitempty"`
+	DevDependencies      map[string]pnpmDependency `yaml:"devDependencies,omitempty"`
+	OptionalDependencies map[string]pnpmDependency `yaml:"optionalDependencies,omitempty"`
+	Packages             map[string]yaml2.MapSlice `yaml:"packages,omitempty"`
+	Snapshots            map[string]yaml2.MapSlice `yaml:"snapshots,omitempty"`
+
+	// single is set for version 6 lockfiles of a single project, without importers.
+	single bool
+}
+
+type pnpmImporter struct {
+	Dependencies         map[string]pnpmDependency `yaml:"dependencies,omitempty"`
+	DevDependencies      map[string]pnpmDependency `yaml:"devDependencies,omitempty"`
+	OptionalDependencies map[string]pnpmDependency `yaml:"optionalDependencies,omitempty"`
+}
+
+type pnpmDependency struct {
+	Specifier string `yaml:"specifier"`
+	Version   string `yaml:"version"`
+}
+
+var pnpmSections = []string{"dependencies", "devDependencies", "optionalDependencies"}
+
+func (*pnpmLockfile) Name() string {
+	return "pnpm-lock.yaml"
+}
+
+func (*pnpmLockfile) Manager() Manager {
+	return Pnpm
+}
+
+func (*pnpmLockfile) Parse(data []byte) (*Lock, error) {
+	var document pnpmDocument
+	if err := yaml2.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("cannot deserialize pnpm-lock.yaml: %v", err)
+	}
+
+	if major := document.major(); major != "6" && major != "9" {
+		return nil, fmt.Errorf("pnpm-lock.yaml lockfileVersion %v is not supported, only 6 and 9 are", document.LockfileVersion)
+	}
+
+	if document.Importers == nil {
+		document.single = true
+		document.Importers = map[string]pnpmImporter{".": {document.Dependencies, document.DevDependencies, document.OptionalDependencies}}
+	}
+
+	resolutions := make(map[string]Resolution)
+	descriptors := make(map[string][]string)
+	for key, snapshot := range document.snapshots() {
+		locator := document.locator(key)
+		resolution, ok := resolutions[locator]
+		if !ok {
+			resolution = document.resolution(key, locator)
+		}
+
+		for _, section := range []string{"dependencies", "optionalDependencies"} {
+			for dependency, value := range pnpmStrings(pnpmField(snapshot, section)) {
+				request, target := document.reference(dependency, value)
+				resolution.addClassicDependency(section, dependency, request)
+				descriptors[target] = append(descriptors[target], npmDescriptor(dependency, request))
+			}
+		}
+
+		resolutions[locator] = resolution
+	}
+
+	workspaces := document.workspaces()
+	for directory, importer := range document.Importers {
+		locator := workspaces[directory]
+		resolution := Resolution{Version: "0.0.0-use.local", Resolution: locator}
+		descriptors[locator] = append(descriptors[locator], locator)
+		for _, section := range pnpmSections {
+			for dependency, spec := range importer.section(section) {
+				resolution.addClassicDependency(strings.Replace(section, "devDependencies", "dependencies", 1), dependency, spec.Specifier)
+				target := workspaces[pnpmLink(directory, spec.Version)]
+				if target == "" {
+					_, target = document.reference(dependency, spec.Version)
+				}
+
+				descriptors[target] = append(descriptors[target], npmDescriptor(dependency, spec.Specifier))
+			}
+		}
+
+		resolutions[locator] = resolution
+	}
+
+	var locators []string
+	for locator := range resolutions {
+		locators = append(locators, locator)
+	}
+
+	sort.Strings(locators)
+
+	// A specifier can be locked to different versions in different importers, the others get an exact descriptor.
+	owners := make(map[string]string)
+	entries := make(map[string]Resolution)
+	for _, locator := range locators {
+		name, reference := splitLocator(locator)
+		exact := name + "@" + reference
+		if len(descriptors[locator]) == 0 {
+			descriptors[locator] = []string{exact}
+		}
+
+		for _, descriptor := range descriptors[locator] {
+			if owner, ok := owners[descriptor]; ok && owner != locator {
+				descriptor = exact
+			}
+
+			owners[descriptor] = locator
+			entries[descriptor] = resolutions[locator]
+		}
+	}
+
+	lock := newLock(&pnpmLockfile{original: &document})
+	lock.group(entries)
+	return lock, nil
+}
+
+func (document *pnpmDocument) major() string {
+	return strings.SplitN(fmt.Sprint(document.LockfileVersion), ".", 2)[0]
+}
+
+// snapshots are the resolved package instances, in the packages of version 6 or the snapshots of version 9.
+func (document *pnpmDocument) snapshots() map[string]yaml2.MapSlice {
+	if document.major() == "6" {
+		return document.Packages
+	}
+
+	return document.Snapshots
+}
+
+// meta is the packages entry of a snapshot, holding its resolution.
+func (document *pnpmDocument) meta(key string) yaml2.MapSlice {
+	if document.major() == "6" {
+		return document.Packages[key]
+	}
+
+	name, version, _ := parsePnpmKey(key)
+	return document.Packages[name+"@"+version]
+}
+
+// parsePnpmKey splits keys like /@scope/name@1.0.0(peer@2.0.0) into the name, the version and the peer suffix.
+func parsePnpmKey(key string) (string, string, string) {
+	key = strings.TrimPrefix(key, "/")
+	peers := ""
+	if loc := strings.Index(key, "("); loc >= 0 {
+		key, peers = key[:loc], key[loc:]
+	}
+
+	name, version := splitLocator(key)
+	return name, version, peers
+}
+
+func (document *pnpmDocument) key(name string, version string) string {
+	if document.major() == "6" {
+		return "/" + name + "@" + version
+	}
+
+	return name + "@" + version
+}
+
+func (document *pnpmDocument) locator(key string) string {
+	name, version, _ := parsePnpmKey(key)
+	meta := document.meta(key)
+	if alias, ok := pnpmField(meta, "name").(string); ok && alias != "" {
+		name = alias
+	}
+
+	if _, err := semver.ParseVersion(version); err != nil {
+		return name + "@" + version
+	}
+
+	return fmt.Sprintf("%s@npm:%s", name, version)
+}
+
+func (document *pnpmDocument) resolution(key string, locator string) Resolution {
+	_, version, _ := parsePnpmKey(key)
+	meta := document.meta(key)
+	if metaVersion, ok := pnpmField(meta, "version").(string); ok && metaVersion != "" {
+		version = metaVersion
+	}
+
+	resolution := Resolution{Version: version, Resolution: locator}
+	details, _ := pnpmField(meta, "resolution").(yaml2.MapSlice)
+	resolution.Integrity, _ = pnpmField(details, "integrity").(string)
+	resolution.Resolved, _ = pnpmField(details, "tarball").(string)
+	resolution.PeerDependencies = pnpmStrings(pnpmField(meta, "peerDependencies"))
+	return resolution
+}
+
+// reference turns the locked version of a dependency, like 1.0.0(peer@2.0.0) or real@1.0.0 for aliases, into a
+// request and the locator it refers to.
+func (document *pnpmDocument) reference(dependency string, value string) (string, string) {
+	name, version, peers := parsePnpmKey(value)
+	if version == "" {
+		name, version = dependency, name
+	}
+
+	key := document.key(name, version+peers)
+	if _, ok := document.snapshots()[key]; !ok {
+		key = document.key(name, version)
+	}
+
+	if name != dependency {
+		return "npm:" + name + "@" + version, document.locator(key)
+	}
+
+	return version, document.locator(key)
+}
+
+// workspaces names the importers after the dependencies linking to them, or their directory.
+func (document *pnpmDocument) workspaces() map[string]string {
+	names := make(map[string]string)
+	for directory, importer := range document.Importers {
+		for _, section := range pnpmSections {
+			for dependency, spec := range importer.section(section) {
+				if target := pnpmLink(directory, spec.Version); target != "" {
+					names[target] = dependency
+				}
+			}
+		}
+	}
+
+	locators := make(map[string]string)
+	for directory := range document.Importers {
+		name := names[directory]
+		switch {
+		case name != "":
+		case directory == ".":
+			name = "root-workspace"
+		default:
+			name = path.Base(directory)
+		}
+
+		locators[directory] = name + "@workspace:" + directory
+	}
+
+	return locators
+}
+
+// pnpmLink resolves link: versions to the importer directory they point to.
+func pnpmLink(directory string, version string) string {
+	if !strings.HasPrefix(version, "link:") {
+		return ""
+	}
+
+	return path.Join(directory, strings.TrimPrefix(version, "link:"))
+}
+
+func (importer pnpmImporter) section(section string) map[string]pnpmDependency {
+	switch section {
+	case "devDependencies":
+		return importer.DevDependencies
+	case "optionalDependencies":
+		return importer.OptionalDependencies
+	default:
+		return importer.Dependencies
+	}
+}
+
+func pnpmField(object yaml2.MapSlice, key string) interface{} {
+	for _, item := range object {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+
+	return nil
+}
+
+func pnpmStrings(value interface{}) map[string]string {
+	object, _ := value.(yaml2.MapSlice)
+	if len(object) == 0 {
+		return nil
+	}
+
+	strings := make(map[string]string)
+	for _, item := range object {
+		strings[fmt.Sprint(item.Key)] = fmt.Sprint(item.Value)
+	}
+
+	return strings
+}
+
+type pnpmEntry struct {
+	key   string
+	value yaml2.MapSlice
+}
+
+// Serialize writes the lock in the format of the original lockfile, version 9 for new ones. Snapshots of unchanged
+// packages are kept as long as everything they refer to is kept too, the others are written without peer suffixes
+// for pnpm install to fill in.
+func (format *pnpmLockfile) Serialize(lock *Lock) ([]byte, error) {
+	original := format.original
+	if original == nil {
+		original = &pnpmDocument{LockfileVersion: "9.0"}
+	}
+
+	locked := make(map[string]Resolution)
+	for _, resolution := range lock.resolutions {
+		locked[resolution.Resolution] = resolution
+	}
+
+	kept := make(map[string]bool)
+	for key := range original.snapshots() {
+		if _, ok := locked[original.locator(key)]; ok {
+			kept[key] = true
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for key := range kept {
+			if !original.refersToKept(key, kept) {
+				delete(kept, key)
+				changed = true
+			}
+		}
+	}
+
+	writer := pnpmWriter{original: original, lock: lock, locked: locked, kept: kept, keys: make(map[string][]string), descriptors: lock.descriptors()}
+	for key := range kept {
+		locator := original.locator(key)
+		writer.keys[locator] = append(writer.keys[locator], key)
+	}
+
+	var generated []string
+	for locator := range locked {
+		name, reference := splitLocator(locator)
+		if len(writer.keys[locator]) == 0 && !strings.HasPrefix(reference, "workspace:") {
+			writer.keys[locator] = []string{original.key(name, strings.TrimPrefix(reference, "npm:"))}
+			generated = append(generated, locator)
+		}
+	}
+
+	for _, keys := range writer.keys {
+		sort.Strings(keys)
+	}
+
+	// Packages depending on removed ones are removed too, pnpm install resolves them again for the importers.
+	for changed := true; changed; {
+		changed = false
+		for i := 0; i < len(generated); i++ {
+			if !writer.complete(generated[i]) {
+				delete(writer.keys, generated[i])
+				generated = append(generated[:i], generated[i+1:]...)
+				i--
+				changed = true
+			}
+		}
+	}
+
+	var snapshots []pnpmEntry
+	for key := range kept {
+		snapshots = append(snapshots, pnpmEntry{key, original.snapshots()[key]})
+	}
+
+	for _, locator := range generated {
+		snapshots = append(snapshots, pnpmEntry{writer.keys[locator][0], writer.snapshot(locator)})
+	}
+
+	sort.Slice(snapshots, func(p, q int) bool { return snapshots[p].key < snapshots[q].key })
+
+	if original.major() == "6" {
+		return writer.write(snapshots, nil), nil
+	}
+
+	var packages []pnpmEntry
+	seen := make(map[string]bool)
+	for _, snapshot := range snapshots {
+		name, version, _ := parsePnpmKey(snapshot.key)
+		key := name + "@" + version
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		if meta, ok := original.Packages[key]; ok && kept[snapshot.key] {
+			packages = append(packages, pnpmEntry{key, meta})
+		} else {
+			packages = append(packages, pnpmEntry{key, writer.meta(writer.resolution(original.locatorOf(snapshot.key, writer.keys)))})
+		}
+	}
+
+	return writer.write(packages, snapshots), nil
+}
+
+// refersToKept tells whether all dependencies of a snapshot refer to kept snapshots.
+func (document *pnpmDocument) refersToKept(key string, kept map[string]bool) bool {
+	snapshot := document.snapshots()[key]
+	for _, section := range []string{"dependencies", "optionalDependencies"} {
+		for dependency, value := range pnpmStrings(pnpmField(snapshot, section)) {
+			if strings.HasPrefix(value, "link:") {
+				continue
+			}
+
+			name, version, peers := parsePnpmKey(value)
+			if version == "" {
+				name, version = dependency, name
+			}
+
+			if !kept[document.key(name, version+peers)] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// locatorOf finds the locator a snapshot key was written for.
+func (document *pnpmDocument) locatorOf(key string, keys map[string][]string) string {
+	for locator, candidates := range keys {
+		if containsString(candidates, key) {
+			return locator
+		}
+	}
+
+	return document.locator(key)
+}
+
+type pnpmWriter struct {
+	original    *pnpmDocument
+	lock        *Lock
+	locked      map[string]Resolution
+	kept        map[string]bool
+	keys        map[string][]string
+	descriptors map[string]string
+}
+
+func (writer *pnpmWriter) resolution(locator string) Resolution {
+	if resolution, ok := writer.locked[locator]; ok {
+		return resolution
+	}
+
+	return Resolution{Resolution: locator}
+}
+
+// complete tells whether all dependencies of a package are written.
+func (writer *pnpmWriter) complete(locator string) bool {
+	for dependency, request := range writer.resolution(locator).Dependencies {
+		if _, _, ok := writer.version(".", dependency, request); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// meta holds the resolution of a package, its integrity or, without one, its tarball.
+func (writer *pnpmWriter) meta(resolution Resolution) yaml2.MapSlice {
+	var details yaml2.MapSlice
+	if resolution.Integrity != "" {
+		details = append(details, yaml2.MapItem{Key: "integrity", Value: resolution.Integrity})
+	} else if resolution.Resolved != "" {
+		details = append(details, yaml2.MapItem{Key: "tarball", Value: resolution.Resolved})
+	}
+
+	meta := yaml2.MapSlice{{Key: "resolution", Value: details}}
+	if _, reference := splitLocator(resolution.Resolution); !strings.HasPrefix(reference, "npm:") {
+		meta = append(meta, yaml2.MapItem{Key: "version", Value: resolution.Version})
+	}
+
+	if len(resolution.PeerDependencies) > 0 {
+		meta = append(meta, yaml2.MapItem{Key: "peerDependencies", Value: pnpmMap(resolution.PeerDependencies)})
+	}
+
+	return meta
+}
+
+func (writer *pnpmWriter) snapshot(locator string) yaml2.MapSlice {
+	resolution := writer.resolution(locator)
+
+	var snapshot yaml2.MapSlice
+	if writer.original.major() == "6" {
+		snapshot = writer.meta(resolution)
+	}
+
+	dependencies, optional := make(map[string]string), make(map[string]string)
+	for dependency, request := range resolution.Dependencies {
+		version, _, ok := writer.version(".", dependency, request)
+		switch {
+		case !ok:
+		case resolution.DependenciesMeta[dependency].Optional:
+			optional[dependency] = version
+		default:
+			dependencies[dependency] = version
+		}
+	}
+
+	if len(dependencies) > 0 {
+		snapshot = append(snapshot, yaml2.MapItem{Key: "dependencies", Value: pnpmMap(dependencies)})
+	}
+
+	if len(optional) > 0 {
+		snapshot = append(snapshot, yaml2.MapItem{Key: "optionalDependencies", Value: pnpmMap(optional)})
+	}
+
+	return snapshot
+}
+
+// version is the locked version of a dependency as written in the lockfile: a version with peers, an alias or a link
+// relative to the importer directory.
+func (writer *pnpmWriter) version(directory string, dependency string, request string) (string, string, bool) {
+	key, ok := resolveDependency(writer.descriptors, dependency, request)
+	if !ok {
+		return "", "", false
+	}
+
+	target := writer.lock.resolutions[key].Resolution
+	name, reference := splitLocator(target)
+	if strings.HasPrefix(reference, "workspace:") {
+		relative, err := filepath.Rel(directory, strings.TrimPrefix(reference, "workspace:"))
+		return "link:" + filepath.ToSlash(relative), target, err == nil
+	}
+
+	keys := writer.keys[target]
+	if len(keys) == 0 {
+		return "", "", false
+	}
+
+	_, version, peers := parsePnpmKey(keys[0])
+	if name != dependency {
+		return writer.original.key(name, version+peers), target, true
+	}
+
+	return version + peers, target, true
+}
+
+func (writer *pnpmWriter) importers() []pnpmEntry {
+	var importers []pnpmEntry
+	for _, resolution := range writer.lock.resolutions {
+		_, reference := splitLocator(resolution.Resolution)
+		if !strings.HasPrefix(reference, "workspace:") {
+			continue
+		}
+
+		directory := strings.TrimPrefix(reference, "workspace:")
+		original := writer.original.Importers[directory]
+		sections := make(map[string]yaml2.MapSlice)
+
+		var dependencies []string
+		for dependency := range resolution.Dependencies {
+			dependencies = append(dependencies, dependency)
+		}
+
+		sort.Strings(dependencies)
+		for _, dependency := range dependencies {
+			request := resolution.Dependencies[dependency]
+			version, target, ok := writer.version(directory, dependency, request)
+			if !ok {
+				continue
+			}
+
+			section := "dependencies"
+			if resolution.DependenciesMeta[dependency].Optional {
+				section = "optionalDependencies"
+			}
+
+			for _, candidate := range pnpmSections {
+				if spec, ok := original.section(candidate)[dependency]; ok {
+					section = candidate
+					if spec.Specifier == request && writer.keeps(directory, dependency, spec.Version, target) {
+						version = spec.Version
+					}
+				}
+			}
+
+			sections[section] = append(sections[section], yaml2.MapItem{Key: dependency, Value: yaml2.MapSlice{
+				{Key: "specifier", Value: request},
+				{Key: "version", Value: version},
+			}})
+		}
+
+		var importer yaml2.MapSlice
+		for _, section := range pnpmSections {
+			if len(sections[section]) > 0 {
+				importer = append(importer, yaml2.MapItem{Key: section, Value: sections[section]})
+			}
+		}
+
+		importers = append(importers, pnpmEntry{directory, importer})
+	}
+
+	sort.Slice(importers, func(p, q int) bool { return importers[p].key < importers[q].key })
+	return importers
+}
+
+// keeps tells whether the original version of an importer dependency still refers to the locked package, it may
+// differ from a new one in its peers.
+func (writer *pnpmWriter) keeps(directory string, dependency string, original string, target string) bool {
+	if strings.HasPrefix(original, "link:") {
+		_, reference := splitLocator(target)
+		return pnpmLink(directory, original) == strings.TrimPrefix(reference, "workspace:")
+	}
+
+	name, version, peers := parsePnpmKey(original)
+	if version == "" {
+		name, version = dependency, name
+	}
+
+	key := writer.original.key(name, version+peers)
+	return writer.kept[key] && writer.original.locator(key) == target
+}
+
+func (writer *pnpmWriter) write(packages []pnpmEntry, snapshots []pnpmEntry) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "lockfileVersion: %s\n", quotePnpm(fmt.Sprint(writer.original.LockfileVersion)))
+
+	settings := writer.original.Settings
+	if settings == nil {
+		settings = yaml2.MapSlice{{Key: "autoInstallPeers", Value: true}, {Key: "excludeLinksFromLockfile", Value: false}}
+	}
+
+	b.WriteString("\nsettings:\n")
+	writePnpmMap(&b, "  ", settings)
+
+	importers := writer.importers()
+	if writer.original.single && len(importers) == 1 && importers[0].key == "." {
+		for _, section := range importers[0].value {
+			fmt.Fprintf(&b, "\n%s:\n", section.Key)
+			writePnpmMap(&b, "  ", section.Value.(yaml2.MapSlice))
+		}
+	} else {
+		writePnpmSection(&b, "importers", importers)
+	}
+
+	writePnpmSection(&b, "packages", packages)
+	if snapshots != nil {
+		writePnpmSection(&b, "snapshots", snapshots)
+	}
+
+	return b.Bytes()
+}
+
+func pnpmMap(values map[string]string) yaml2.MapSlice {
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var object yaml2.MapSlice
+	for _, name := range names {
+		object = append(object, yaml2.MapItem{Key: name, Value: values[name]})
+	}
+
+	return object
+}
+
+func writePnpmSection(b *bytes.Buffer, name string, entries []pnpmEntry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "\n%s:\n", name)
+	for _, entry := range entries {
+		if len(entry.value) == 0 {
+			fmt.Fprintf(b, "\n  %s: {}\n", quotePnpm(entry.key))
+			continue
+		}
+
+		fmt.Fprintf(b, "\n  %s:\n", quotePnpm(entry.key))
+		writePnpmMap(b, "    ", entry.value)
+	}
+}
+
+// pnpmFlow are the fields pnpm writes in flow style, like resolution: {integrity: sha512-...}.
+var pnpmFlow = map[string]bool{"resolution": true, "engines": true, "cpu": true, "os": true, "libc": true}
+
+func writePnpmMap(b *bytes.Buffer, indent string, object yaml2.MapSlice) {
+	for _, item := range object {
+		key := fmt.Sprint(item.Key)
+		switch value := item.Value.(type) {
+		case yaml2.MapSlice:
+			switch {
+			case len(value) == 0 || pnpmFlow[key]:
+				fmt.Fprintf(b, "%s%s: %s\n", indent, quotePnpm(key), pnpmFlowValue(value))
+			default:
+				fmt.Fprintf(b, "%s%s:\n", indent, quotePnpm(key))
+				writePnpmMap(b, indent+"  ", value)
+			}
+		case []interface{}:
+			if pnpmFlow[key] {
+				fmt.Fprintf(b, "%s%s: %s\n", indent, quotePnpm(key), pnpmFlowValue(value))
+				continue
+			}
+
+			fmt.Fprintf(b, "%s%s:\n", indent, quotePnpm(key))
+			for _, element := range value {
+				fmt.Fprintf(b, "%s  - %s\n", indent, pnpmFlowValue(element))
+			}
+		default:
+			fmt.Fprintf(b, "%s%s: %s\n", indent, quotePnpm(key), pnpmFlowValue(value))
+		}
+	}
+}
+
+func pnpmFlowValue(value interface{}) string {
+	switch value := value.(type) {
+	case yaml2.MapSlice:
+		var items []string
+		for _, item := range value {
+			items = append(items, quotePnpm(fmt.Sprint(item.Key))+": "+pnpmFlowValue(item.Value))
+		}
+
+		return "{" + strings.Join(items, ", ") + "}"
+	case []interface{}:
+		var elements []string
+		for _, element := range value {
+			elements = append(elements, pnpmFlowValue(element))
+		}
+
+		return "[" + strings.Join(elements, ", ") + "]"
+	case string:
+		return quotePnpm(value)
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+var (
+	pnpmSpecial  = regexp.MustCompile("^[-?:,\\[\\]{}#&*!|>'\"%@` ]|[,\\[\\]{}]|: | #|:$| $")
+	pnpmImplicit = regexp.MustCompile(`^(?i:true|false|yes|no|on|off|y|n|null|~|[-+]?(\.[0-9]+|[0-9][0-9_]*(\.[0-9]*)?)([eE][-+]?[0-9]+)?|0x[0-9a-f]+|0o[0-7]+)$`)
+)
+
+// quotePnpm single-quotes the strings YAML would not read back as the same plain string, like pnpm does.
+func quotePnpm(value string) string {
+	if value != "" && !pnpmSpecial.MatchString(value) && !pnpmImplicit.MatchString(value) {
+		return value
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,205 @@
+package yarn_test
+
+import (
+	"gnarl/yarn"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const pnpmLock = `lockfileVersion: '9.0'
+
+settings:
+  autoInstallPeers: true
+  excludeLinksFromLockfile: false
+
+importers:
+
+  .:
+    dependencies:
+      left:
+        specifier: ^1.0.0
+        version: 1.2.0(react@18.2.0)
+      lodash:
+        specifier: ^4.17.20
+        version: 4.17.20
+      react:
+        specifier: ^18.2.0
+        version: 18.2.0
+    devDependencies:
+      tool:
+        specifier: workspace:*
+        version: link:packages/tool
+
+  packages/tool:
+    dependencies:
+      lodash:
+        specifier: ^4.17.0
+        version: 4.17.20
+
+packages:
+
+  left@1.2.0:
+    resolution: {integrity: sha512-left==}
+    peerDependencies:
+      react: '>=17'
+
+  lodash@3.10.1:
+    resolution: {integrity: sha512-lodash3==}
+
+  lodash@4.17.20:
+    resolution: {integrity: sha512-lodash4==}
+    engines: {node: '>=12'}
+
+  react@18.2.0:
+    resolution: {integrity: sha512-react==}
+
+snapshots:
+
+  left@1.2.0(react@18.2.0):
+    dependencies:
+      lodash: 3.10.1
+      react: 18.2.0
+
+  lodash@3.10.1: {}
+
+  lodash@4.17.20: {}
+
+  react@18.2.0: {}
+`
+
+const pnpmLockV6 = `lockfileVersion: '6.0'
+
+settings:
+  autoInstallPeers: true
+  excludeLinksFromLockfile: false
+
+dependencies:
+  left:
+    specifier: ^1.0.0
+    version: 1.2.0
+  string-width-cjs:
+    specifier: npm:string-width@^4.2.0
+    version: /string-width@4.2.3
+
+packages:
+
+  /left@1.2.0:
+    resolution: {integrity: sha512-left==}
+    dependencies:
+      string-width: 4.2.3
+    dev: false
+
+  /string-width@4.2.3:
+    resolution: {integrity: sha512-sw==}
+    dev: false
+`
+
+func mustWritePnpmLock(t *testing.T, lock *yarn.Lock) string {
+	file := filepath.Join(t.TempDir(), "pnpm-lock.yaml")
+	if err := lock.WriteFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(written)
+}
+
+func TestPnpmLock(t *testing.T) {
+	for _, test := range []struct {
+		lock     string
+		expected string
+	}{
+		{pnpmLock, "left@1.2.0 lodash@3.10.1 lodash@4.17.20 react@18.2.0"},
+		{pnpmLockV6, "left@1.2.0 string-width@4.2.3"},
+	} {
+		lock := mustParseLock(t, test.lock)
+		if lock.Manager() != yarn.Pnpm {
+			t.Fatalf("expected a pnpm lockfile, got %s", lock.Manager())
+		}
+
+		if actual := lockedLocators(lock); actual != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, actual)
+		}
+
+		if written := mustWritePnpmLock(t, lock); written != test.lock {
+			t.Errorf("expected an unchanged pnpm-lock.yaml, got\n%s", written)
+		}
+	}
+}
+
+func TestPnpmLockWhy(t *testing.T) {
+	lock := mustParseLock(t, pnpmLock)
+	pattern, err := yarn.ParseResetPattern("lodash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chains, _ := lock.Why(pattern, 10)
+	var actual []string
+	for _, chain := range chains {
+		actual = append(actual, strings.Join(chain, " > "))
+	}
+
+	expected := "root-workspace@workspace:. > lodash@npm:4.17.20, " +
+		"root-workspace@workspace:. > left@npm:1.2.0 > lodash@npm:3.10.1, " +
+		"root-workspace@workspace:. > tool@workspace:packages/tool > lodash@npm:4.17.20"
+	if strings.Join(actual, ", ") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(actual, ", "))
+	}
+}
+
+func TestPnpmLockReset(t *testing.T) {
+	lock := mustParseLock(t, pnpmLock)
+	pattern, err := yarn.ParseResetPattern("lodash@^3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock.ResetMatching(pattern, false)
+	written := mustWritePnpmLock(t, lock)
+	if strings.Contains(written, "left") {
+		t.Errorf("expected the dependents of lodash 3 to be removed, got\n%s", written)
+	}
+
+	if actual := lockedLocators(mustParseLock(t, written)); actual != "lodash@4.17.20 react@18.2.0" {
+		t.Errorf("unexpected packages %s", actual)
+	}
+}
+
+func TestPnpmLockUpgrade(t *testing.T) {
+	lock := mustParseLock(t, pnpmLock)
+	metadata := fakeMetadata{
+		"lodash": {Versions: map[string]yarn.Manifest{
+			"4.17.20": {Version: "4.17.20"},
+			"4.17.21": {Version: "4.17.21", Dist: yarn.Dist{Integrity: "sha512-new=="}},
+		}},
+	}
+
+	pattern, err := yarn.ParseResetPattern("lodash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lock.Upgrade(pattern, metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	written := mustWritePnpmLock(t, lock)
+	if !strings.Contains(written, "lodash@4.17.21:\n    resolution: {integrity: sha512-new==}") || strings.Contains(written, "lodash@4.17.20") {
+		t.Errorf("expected lodash to be upgraded to 4.17.21, got\n%s", written)
+	}
+
+	if !strings.Contains(written, "version: 1.2.0(react@18.2.0)") {
+		t.Errorf("expected the peers of left to be kept, got\n%s", written)
+	}
+
+	if actual := lockedLocators(mustParseLock(t, written)); actual != "left@1.2.0 lodash@3.10.1 lodash@4.17.21 react@18.2.0" {
+		t.Errorf("unexpected packages %s", actual)
+	}
+}
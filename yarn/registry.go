@@ -0,0 +1,245 @@
+package yarn
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gnarl/semver"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const DefaultRegistry = "https://registry.yarnpkg.com"
+
+type Registry struct {
+	Server      string
+	AuditServer string
+	Token       string
+	Ident       string
+	Scopes      map[string]*Registry
+	Client      *http.Client
+
+	// Full requests complete packuments, which have the licenses abbreviated ones leave out.
+	Full bool
+}
+
+type Metadata interface {
+	Packument(npmPackage string) (*Packument, error)
+}
+
+type Packument struct {
+	Name     string              `json:"name"`
+	DistTags map[string]string   `json:"dist-tags,omitempty"`
+	Versions map[string]Manifest `json:"versions"`
+}
+
+type Manifest struct {
+	Name                 string            `json:"name,omitempty"`
+	Version              string            `json:"version"`
+	Deprecated           string            `json:"deprecated,omitempty"`
+	Dependencies         map[string]string `json:"dependencies,omitempty"`
+	OptionalDependencies map[string]string `json:"optionalDependencies,omitempty"`
+	PeerDependencies     map[string]string `json:"peerDependencies,omitempty"`
+	Bin                  json.RawMessage   `json:"bin,omitempty"`
+	Dist                 Dist              `json:"dist,omitempty"`
+
+	// License and Licenses are in package.json and full registry documents, abbreviated ones leave them out.
+	License  json.RawMessage `json:"license,omitempty"`
+	Licenses json.RawMessage `json:"licenses,omitempty"`
+}
+
+type Dist struct {
+	Tarball   string `json:"tarball,omitempty"`
+	Integrity string `json:"integrity,omitempty"`
+	Shasum    string `json:"shasum,omitempty"`
+}
+
+type BulkAdvisory struct {
+	Id                 int      `json:"id"`
+	Url                string   `json:"url"`
+	Title              string   `json:"title"`
+	Severity           string   `json:"severity"`
+	VulnerableVersions string   `json:"vulnerable_versions"`
+	Cwe                []string `json:"cwe,omitempty"`
+}
+
+func NewRegistry(config *Config) *Registry {
+	server := config.NpmRegistryServer
+	if server == "" {
+		server = DefaultRegistry
+	}
+
+	auditServer := config.NpmAuditRegistry
+	if auditServer == "" {
+		auditServer = server
+	}
+
+	registry := &Registry{
+		Server:      strings.TrimSuffix(server, "/"),
+		AuditServer: strings.TrimSuffix(auditServer, "/"),
+		Token:       config.NpmAuthToken,
+		Ident:       config.NpmAuthIdent,
+		Scopes:      make(map[string]*Registry),
+		Client:      &http.Client{Timeout: time.Minute},
+	}
+
+	for scope, settings := range config.NpmScopes {
+		scoped := &Registry{Server: registry.Server, Token: settings.NpmAuthToken, Ident: settings.NpmAuthIdent, Client: registry.Client}
+		if settings.NpmRegistryServer != "" {
+			scoped.Server = strings.TrimSuffix(settings.NpmRegistryServer, "/")
+		}
+
+		registry.Scopes[scope] = scoped
+	}
+
+	return registry
+}
+
+// scoped returns the registry configured for the scope of a package in npmScopes.
+func (registry *Registry) scoped(npmPackage string) *Registry {
+	if strings.HasPrefix(npmPackage, "@") && strings.Contains(npmPackage, "/") {
+		if scoped, ok := registry.Scopes[npmPackage[1:strings.Index(npmPackage, "/")]]; ok {
+			return scoped
+		}
+	}
+
+	return registry
+}
+
+func (registry *Registry) BulkAdvisories(lock *Lock) ([]Advisory, error) {
+	versions := make(map[string][]string)
+	for _, locked := range lock.Packages() {
+		versions[locked.Name] = append(versions[locked.Name], locked.Version.String())
+	}
+
+	return registry.BulkAdvisoriesFor(versions)
+}
+
+// BulkAdvisoriesFor returns the advisories affecting the given versions, by package name.
+func (registry *Registry) BulkAdvisoriesFor(versions map[string][]string) ([]Advisory, error) {
+	body, err := json.Marshal(versions)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serialize bulk advisory request: %v", err)
+	}
+
+	url := fmt.Sprintf("%s/-/npm/v1/security/advisories/bulk", registry.AuditServer)
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create bulk advisory request: %v", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	registry.authorize(request)
+
+	response, err := registry.Client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("cannot request bulk advisories: %v", err)
+	}
+
+	defer response.Body.Close()
+
+	payload, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read bulk advisories: %v", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bulk advisory request failed: %s: %s", response.Status, strings.TrimSpace(string(payload)))
+	}
+
+	return ParseBulkAdvisories(payload)
+}
+
+func (registry *Registry) Packument(npmPackage string) (*Packument, error) {
+	accept := "application/vnd.npm.install-v1+json; q=1.0, application/json; q=0.8"
+	if registry.Full {
+		accept = "application/json"
+	}
+
+	registry = registry.scoped(npmPackage)
+	url := fmt.Sprintf("%s/%s", registry.Server, strings.Replace(npmPackage, "/", "%2f", 1))
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create metadata request for %s: %v", npmPackage, err)
+	}
+
+	request.Header.Set("Accept", accept)
+	registry.authorize(request)
+
+	response, err := registry.Client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("cannot request metadata for %s: %v", npmPackage, err)
+	}
+
+	defer response.Body.Close()
+
+	payload, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read metadata for %s: %v", npmPackage, err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata request for %s failed: %s", npmPackage, response.Status)
+	}
+
+	packument := Packument{}
+	err = json.Unmarshal(payload, &packument)
+	if err != nil {
+		return nil, fmt.Errorf("cannot deserialize metadata for %s: %v", npmPackage, err)
+	}
+
+	return &packument, nil
+}
+
+func (registry *Registry) authorize(request *http.Request) {
+	switch {
+	case registry.Token != "":
+		request.Header.Set("Authorization", "Bearer "+registry.Token)
+	case registry.Ident != "":
+		request.Header.Set("Authorization", "Basic "+registry.Ident)
+	}
+}
+
+func ParseBulkAdvisories(payload []byte) ([]Advisory, error) {
+	bulk := make(map[string][]BulkAdvisory)
+	err := json.Unmarshal(payload, &bulk)
+	if err != nil {
+		return nil, fmt.Errorf("cannot deserialize bulk advisories: %v", err)
+	}
+
+	var advisories []Advisory
+	for npmPackage, found := range bulk {
+		for _, advisory := range found {
+			vulnerable, err := semver.ParseRequest(advisory.VulnerableVersions)
+			if err != nil {
+				log.Printf("skipping advisory %d for %s: %v", advisory.Id, npmPackage, err)
+				continue
+			}
+
+			advisories = append(advisories, Advisory{
+				Id:                 advisory.Id,
+				GithubAdvisoryId:   githubAdvisoryIdFromUrl(advisory.Url),
+				Title:              advisory.Title,
+				Severity:           advisory.Severity,
+				Url:                advisory.Url,
+				ModuleName:         npmPackage,
+				VulnerableVersions: advisory.VulnerableVersions,
+				PatchedVersions:    vulnerable.Patches().String(),
+			})
+		}
+	}
+
+	sort.Slice(advisories, func(p, q int) bool {
+		if advisories[p].ModuleName != advisories[q].ModuleName {
+			return advisories[p].ModuleName < advisories[q].ModuleName
+		}
+
+		return advisories[p].Id < advisories[q].Id
+	})
+
+	return advisories, nil
+}
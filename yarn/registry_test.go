@@ -0,0 +1,89 @@
+package yarn_test
+
+import (
+	"encoding/json"
+	"gnarl/yarn"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBulkAdvisories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/-/npm/v1/security/advisories/bulk" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Missing authorization, got %q", r.Header.Get("Authorization"))
+		}
+
+		var versions map[string][]string
+		if err := json.NewDecoder(r.Body).Decode(&versions); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(versions) != 2 || versions["lodash"][0] != "4.17.20" || versions["@scope/left"][0] != "1.2.0" {
+			t.Errorf("Unexpected versions %v", versions)
+		}
+
+		w.Write([]byte(`{"lodash": [{
+			"id": 1106913,
+			"url": "https://github.com/advisories/GHSA-35jh-r3h4-6jhm",
+			"title": "Command Injection in lodash",
+			"severity": "high",
+			"vulnerable_versions": "<4.17.21"
+		}]}`))
+	}))
+	defer server.Close()
+
+	lock, err := yarn.ReadLock(writeTestLock(t, testLock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := yarn.NewRegistry(&yarn.Config{NpmRegistryServer: server.URL + "/", NpmAuthToken: "secret"})
+	advisories, err := registry.BulkAdvisories(lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(advisories) != 1 {
+		t.Fatalf("Expected one advisory, got %v", advisories)
+	}
+
+	advisory := advisories[0]
+	if advisory.ModuleName != "lodash" || advisory.GithubAdvisoryId != "GHSA-35jh-r3h4-6jhm" || advisory.PatchedVersions != "^4.17.21" {
+		t.Errorf("Unexpected advisory %v", advisory)
+	}
+}
+
+func TestScopedPackument(t *testing.T) {
+	scoped := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/@internal%2fwidgets" && r.URL.RawPath != "/@internal%2fwidgets" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+
+		if r.Header.Get("Authorization") != "Bearer internal" {
+			t.Errorf("Expected the scope token, got %q", r.Header.Get("Authorization"))
+		}
+
+		w.Write([]byte(`{"name": "@internal/widgets", "versions": {"1.0.0": {"version": "1.0.0"}}}`))
+	}))
+	defer scoped.Close()
+
+	registry := yarn.NewRegistry(&yarn.Config{
+		NpmRegistryServer: "http://127.0.0.1:1",
+		NpmAuthToken:      "public",
+		NpmScopes:         map[string]yarn.ScopeConfig{"internal": {NpmRegistryServer: scoped.URL, NpmAuthToken: "internal"}},
+	})
+
+	packument, err := registry.Packument("@internal/widgets")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if packument.Name != "@internal/widgets" {
+		t.Errorf("Unexpected packument %v", packument)
+	}
+}
@@ -0,0 +1,129 @@
+package yarn
+
+import (
+	"fmt"
+	"gnarl/semver"
+	"log"
+	"path"
+	"sort"
+	"strings"
+)
+
+type ResetPattern struct {
+	Package string
+	Range   string
+	Request *semver.Request
+}
+
+// ParseResetPattern accepts package names, globs like @babel/* and either with a range, like pkg@^1 or pkg@1.2.3.
+func ParseResetPattern(pattern string) (*ResetPattern, error) {
+	name, rng := splitLocator(pattern)
+	if _, err := path.Match(name, ""); err != nil {
+		return nil, fmt.Errorf("invalid package pattern %s: %v", name, err)
+	}
+
+	if rng == "" {
+		return &ResetPattern{Package: name}, nil
+	}
+
+	request, err := semver.ParseRequest(strings.TrimPrefix(rng, "npm:"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid range %s: %v", rng, err)
+	}
+
+	return &ResetPattern{Package: name, Range: rng, Request: request}, nil
+}
+
+func (pattern *ResetPattern) matches(key string, resolution Resolution) bool {
+	name, _ := splitLocator(resolution.Resolution)
+	if matched, _ := path.Match(pattern.Package, name); !matched {
+		return false
+	}
+
+	if pattern.Request == nil {
+		return true
+	}
+
+	if version, err := semver.ParseVersion(resolution.Version); err == nil && pattern.Request.Matches(version) {
+		return true
+	}
+
+	for _, descriptor := range strings.Split(key, ", ") {
+		if _, rng := splitLocator(descriptor); rng == pattern.Range || rng == "npm:"+pattern.Range {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matching returns the locked npm packages matching the pattern.
+func (lock *Lock) Matching(pattern *ResetPattern) []LockedPackage {
+	var packages []LockedPackage
+	for _, locked := range lock.Packages() {
+		if pattern.matches(locked.Key, lock.resolutions[locked.Key]) {
+			packages = append(packages, locked)
+		}
+	}
+
+	return packages
+}
+
+func (lock *Lock) ResetMatching(pattern *ResetPattern, transitive bool) {
+	reset := make(map[string]bool)
+	for key, resolution := range lock.resolutions {
+		if _, reference := splitLocator(resolution.Resolution); reference == "" || strings.HasPrefix(reference, "workspace:") {
+			continue
+		}
+
+		if pattern.matches(key, resolution) {
+			reset[key] = true
+		}
+	}
+
+	if transitive {
+		lock.addExclusiveDependencies(reset)
+	}
+
+	var keys []string
+	for key := range reset {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		log.Printf("Reset %s", lock.resolutions[key].Resolution)
+		lock.dirty = true
+		delete(lock.resolutions, key)
+	}
+}
+
+// addExclusiveDependencies extends the reset entries with the dependencies nothing else depends on.
+func (lock *Lock) addExclusiveDependencies(reset map[string]bool) {
+	dependencies, dependents := lock.edges()
+
+	for changed := true; changed; {
+		changed = false
+		for key := range reset {
+			for _, dependency := range dependencies[key] {
+				if reset[dependency] {
+					continue
+				}
+
+				exclusive := true
+				for _, dependent := range dependents[dependency] {
+					if !reset[dependent] {
+						exclusive = false
+						break
+					}
+				}
+
+				if exclusive {
+					reset[dependency] = true
+					changed = true
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package yarn_test
+
+import (
+	"gnarl/yarn"
+	"strings"
+	"testing"
+)
+
+const resetLock = testLock + `
+"@scope/right@npm:^1.0.0, @scope/right@npm:^1.1.0":
+  version: 1.1.0
+  resolution: "@scope/right@npm:1.1.0"
+  dependencies:
+    only-right: ^3.0.0
+  languageName: node
+  linkType: hard
+
+"@scope/right@npm:^2.0.0":
+  version: 2.0.0
+  resolution: "@scope/right@npm:2.0.0"
+  languageName: node
+  linkType: hard
+
+"only-right@npm:^3.0.0":
+  version: 3.0.0
+  resolution: "only-right@npm:3.0.0"
+  languageName: node
+  linkType: hard
+`
+
+func lockedLocators(lock *yarn.Lock) string {
+	var locators []string
+	for _, locked := range lock.Packages() {
+		locators = append(locators, locked.Name+"@"+locked.Version.String())
+	}
+
+	return strings.Join(locators, " ")
+}
+
+func TestResetMatching(t *testing.T) {
+	for _, test := range []struct {
+		pattern    string
+		transitive bool
+		expected   string
+	}{
+		{"@scope/right@^1", false, "@scope/left@1.2.0 @scope/right@2.0.0 lodash@4.17.20 only-right@3.0.0"},
+		{"@scope/right@^1", true, "@scope/left@1.2.0 @scope/right@2.0.0 lodash@4.17.20"},
+		{"@scope/right@2.0.0", false, "@scope/left@1.2.0 @scope/right@1.1.0 lodash@4.17.20 only-right@3.0.0"},
+		{"@scope/*", true, "lodash@4.17.20"},
+		{"lodash@^4.17.0", false, "@scope/left@1.2.0 @scope/right@1.1.0 @scope/right@2.0.0 only-right@3.0.0"},
+		{"lodash@^5", false, "@scope/left@1.2.0 @scope/right@1.1.0 @scope/right@2.0.0 lodash@4.17.20 only-right@3.0.0"},
+	} {
+		lock := mustParseLock(t, resetLock)
+		pattern, err := yarn.ParseResetPattern(test.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lock.ResetMatching(pattern, test.transitive)
+		if actual := lockedLocators(lock); actual != test.expected {
+			t.Errorf("Reset %s (transitive %v): expected %s, got %s", test.pattern, test.transitive, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package yarn
+
+import (
+	"fmt"
+	"gnarl/semver"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ResolutionKey is a parsed key of the resolutions field, like lodash, lodash@^4.0.0, @babel/core/lodash or **/lodash.
+type ResolutionKey struct {
+	From      string
+	FromRange string
+	Name      string
+	Range     string
+
+	// MatchVersions applies the range to the resolved versions, like npm and pnpm do, instead of to the requested ranges.
+	MatchVersions bool
+}
+
+type ResolutionAnalysis struct {
+	Source            string            `json:"source"`
+	Key               string            `json:"key"`
+	Value             string            `json:"value"`
+	Name              string            `json:"name,omitempty"`
+	Error             string            `json:"error,omitempty"`
+	Ranges            []string          `json:"ranges,omitempty"`
+	Version           string            `json:"version,omitempty"`
+	Advisories        []string          `json:"advisories,omitempty"`
+	Newer             string            `json:"newer,omitempty"`
+	Natural           map[string]string `json:"natural,omitempty"`
+	NaturalAdvisories []string          `json:"naturalAdvisories,omitempty"`
+	Prunable          bool              `json:"prunable"`
+}
+
+func ParseResolutionKey(key string) (*ResolutionKey, error) {
+	parents, name, rng, err := parseOverridePath(key, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	override := Override{Parents: parents, Name: name, Range: rng}
+	if _, err := path.Match(name, ""); err != nil {
+		return nil, fmt.Errorf("invalid resolution %s: %v", key, err)
+	}
+
+	return override.ResolutionKey(), nil
+}
+
+// splitSpec splits a/b into a and b, keeping scoped names like @babel/core together.
+func splitSpec(spec string) (string, string) {
+	start := 0
+	if strings.HasPrefix(spec, "@") {
+		if loc := strings.Index(spec, "/"); loc >= 0 {
+			start = loc + 1
+		}
+	}
+
+	loc := strings.Index(spec[start:], "/")
+	if loc < 0 {
+		return spec, ""
+	}
+
+	return spec[:start+loc], spec[start+loc+1:]
+}
+
+func (key *ResolutionKey) String() string {
+	descriptor := key.Name
+	if key.Range != "" {
+		descriptor += "@" + key.Range
+	}
+
+	if key.From == "" {
+		return descriptor
+	}
+
+	from := key.From
+	if key.FromRange != "" {
+		from += "@" + key.FromRange
+	}
+
+	return from + "/" + descriptor
+}
+
+// ranges lists the requested ranges of the dependencies the resolution applies to.
+func (lock *Lock) ranges(key *ResolutionKey) []string {
+	descriptors := lock.descriptors()
+	versions, _ := semver.ParseRequest(strings.TrimPrefix(key.Range, "npm:"))
+
+	found := make(map[string]bool)
+	for parentKey, parent := range lock.resolutions {
+		if !key.affectsParent(parentKey, parent) {
+			continue
+		}
+
+		for name, request := range parent.Dependencies {
+			if name != key.Name {
+				continue
+			}
+
+			if key.Range == "" || request == key.Range || strings.TrimPrefix(request, "npm:") == strings.TrimPrefix(key.Range, "npm:") {
+				found[request] = true
+				continue
+			}
+
+			if resolved, ok := resolveDependency(descriptors, name, request); ok && key.MatchVersions && versions != nil {
+				if version, err := semver.ParseVersion(lock.resolutions[resolved].Version); err == nil && versions.Matches(version) {
+					found[request] = true
+				}
+			}
+		}
+	}
+
+	var ranges []string
+	for request := range found {
+		ranges = append(ranges, request)
+	}
+
+	sort.Strings(ranges)
+	return ranges
+}
+
+func (key *ResolutionKey) affectsParent(parentKey string, parent Resolution) bool {
+	if key.From == "" {
+		return true
+	}
+
+	pattern := ResetPattern{Package: key.From, Range: key.FromRange}
+	if key.FromRange != "" {
+		pattern.Request, _ = semver.ParseRequest(strings.TrimPrefix(key.FromRange, "npm:"))
+	}
+
+	return pattern.matches(parentKey, parent)
+}
+
+// forcedVersion finds the locked version the value of the resolution resolves to.
+func (lock *Lock) forcedVersion(name string, value string) string {
+	if key, ok := resolveDependency(lock.descriptors(), name, value); ok {
+		return lock.resolutions[key].Version
+	}
+
+	request, err := semver.ParseRequest(strings.TrimPrefix(value, "npm:"))
+	if err != nil {
+		return ""
+	}
+
+	var newest *semver.Version
+	for _, locked := range lock.Packages() {
+		if locked.Name == name && request.Matches(locked.Version) && (newest == nil || locked.Version.Compare(newest) > 0) {
+			newest = locked.Version
+		}
+	}
+
+	if newest == nil {
+		return ""
+	}
+
+	return newest.String()
+}
+
+// AnalyzeResolutions tells for every override which ranges it affects, which version it forces, whether a newer
+// version is available and what the ranges would resolve to without it. Natural resolutions need metadata.
+func (lock *Lock) AnalyzeResolutions(overrides []Override, metadata Metadata) []ResolutionAnalysis {
+	var analyses []ResolutionAnalysis
+	for _, override := range overrides {
+		analysis := ResolutionAnalysis{Source: override.Source, Key: override.Key, Value: override.Value}
+		parsed := override.ResolutionKey()
+		if _, err := path.Match(parsed.Name, ""); err != nil {
+			analysis.Error = fmt.Sprintf("invalid package pattern %s: %v", parsed.Name, err)
+			analyses = append(analyses, analysis)
+			continue
+		}
+
+		analysis.Name = parsed.Name
+		analysis.Ranges = lock.ranges(parsed)
+		analysis.Version = lock.forcedVersion(parsed.Name, override.Value)
+		analysis.Prunable = len(analysis.Ranges) == 0
+
+		if metadata != nil && len(analysis.Ranges) > 0 && !strings.ContainsAny(parsed.Name, "*?[") {
+			if packument, err := metadata.Packument(parsed.Name); err == nil {
+				analysis.analyzeMetadata(packument)
+			} else {
+				analysis.Error = err.Error()
+			}
+		}
+
+		analyses = append(analyses, analysis)
+	}
+
+	return analyses
+}
+
+func (analysis *ResolutionAnalysis) analyzeMetadata(packument *Packument) {
+	if forced, err := semver.ParseVersion(analysis.Version); err == nil {
+		newest := newestSatisfying(packument, fmt.Sprintf("^%d.0.0", forced.Major), analysis.Version)
+		if newest != "" && newest != analysis.Version {
+			analysis.Newer = newest
+		}
+	}
+
+	analysis.Natural = make(map[string]string)
+	for _, rng := range analysis.Ranges {
+		analysis.Natural[rng] = newestSatisfying(packument, strings.TrimPrefix(rng, "npm:"), "")
+	}
+}
+
+// NaturalVersions lists the versions the analyzed ranges would resolve to without their resolutions, by package.
+func NaturalVersions(analyses []ResolutionAnalysis) map[string][]string {
+	versions := make(map[string][]string)
+	for _, analysis := range analyses {
+		for _, natural := range analysis.Natural {
+			if natural != "" {
+				versions[analysis.Name] = append(versions[analysis.Name], natural)
+			}
+		}
+	}
+
+	return versions
+}
+
+// AssessResolutions finds the advisories for the forced and the natural versions. A resolution is prunable when it
+// is unused, or when all ranges it affects would resolve to a safe version at least as high as the forced one.
+func AssessResolutions(analyses []ResolutionAnalysis, advisories []Advisory) {
+	for i := range analyses {
+		analysis := &analyses[i]
+		if analysis.Name == "" {
+			continue
+		}
+
+		analysis.Advisories = vulnerabilities(analysis.Name, analysis.Version, advisories)
+		analysis.NaturalAdvisories = nil
+		if len(analysis.Ranges) == 0 {
+			analysis.Prunable = true
+			continue
+		}
+
+		prunable := len(analysis.Natural) == len(analysis.Ranges)
+		for _, rng := range analysis.Ranges {
+			natural := analysis.Natural[rng]
+			found := vulnerabilities(analysis.Name, natural, advisories)
+			for _, id := range found {
+				if !containsString(analysis.NaturalAdvisories, id) {
+					analysis.NaturalAdvisories = append(analysis.NaturalAdvisories, id)
+				}
+			}
+
+			if natural == "" || len(found) > 0 || !atLeast(natural, analysis.Version) {
+				prunable = false
+			}
+		}
+
+		analysis.Prunable = prunable
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func atLeast(version string, minimum string) bool {
+	v, err := semver.ParseVersion(version)
+	if err != nil {
+		return false
+	}
+
+	m, err := semver.ParseVersion(minimum)
+	if err != nil {
+		return true
+	}
+
+	return v.Compare(m) >= 0
+}
+
+func vulnerabilities(name string, version string, advisories []Advisory) []string {
+	parsed, err := semver.ParseVersion(version)
+	if err != nil {
+		return nil
+	}
+
+	var ids []string
+	for _, advisory := range advisories {
+		if advisory.ModuleName != name {
+			continue
+		}
+
+		if vulnerable, err := semver.ParseRequest(advisory.VulnerableVersions); err == nil && vulnerable.Matches(parsed) {
+			ids = append(ids, advisory.Identifier())
+		}
+	}
+
+	return ids
+}
@@ -0,0 +1,129 @@
+package yarn_test
+
+import (
+	"fmt"
+	"gnarl/yarn"
+	"testing"
+)
+
+func TestParseResolutionKey(t *testing.T) {
+	for key, expected := range map[string]yarn.ResolutionKey{
+		"lodash":                   {Name: "lodash"},
+		"**/lodash":                {Name: "lodash"},
+		"lodash@^4.17.0":           {Name: "lodash", Range: "^4.17.0"},
+		"lodash@npm:^4.17.0":       {Name: "lodash", Range: "npm:^4.17.0"},
+		"@scope/left":              {Name: "@scope/left"},
+		"@scope/left@^1.0.0":       {Name: "@scope/left", Range: "^1.0.0"},
+		"@scope/left/lodash":       {From: "@scope/left", Name: "lodash"},
+		"@scope/left@1.2.0/lodash": {From: "@scope/left", FromRange: "1.2.0", Name: "lodash"},
+		"app/@scope/left@^1":       {From: "app", Name: "@scope/left", Range: "^1"},
+		"a/**/b/c":                 {From: "b", Name: "c"},
+	} {
+		parsed, err := yarn.ParseResolutionKey(key)
+		if err != nil {
+			t.Errorf("Cannot parse %s: %v", key, err)
+			continue
+		}
+
+		if *parsed != expected {
+			t.Errorf("Expected %s to parse as %+v, got %+v", key, expected, *parsed)
+		}
+	}
+
+	if _, err := yarn.ParseResolutionKey("a/**"); err == nil {
+		t.Error("Expected a missing package to be rejected")
+	}
+}
+
+func TestAnalyzeResolutions(t *testing.T) {
+	lock, err := yarn.ReadLock(writeTestLock(t, testLock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := fakeMetadata{"lodash": {Versions: map[string]yarn.Manifest{
+		"4.17.20": {Version: "4.17.20"},
+		"4.17.21": {Version: "4.17.21"},
+	}}}
+	advisories := []yarn.Advisory{{Id: 1, ModuleName: "lodash", VulnerableVersions: "<4.17.21"}}
+
+	overrides, err := (&yarn.Package{Resolutions: map[string]string{
+		"@scope/left/lodash": "4.17.20",
+		"lodash@^3.0.0":      "3.10.1",
+		"other/lodash":       "4.17.21",
+	}}).Overrides()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyses := lock.AnalyzeResolutions(overrides, metadata)
+	yarn.AssessResolutions(analyses, advisories)
+
+	if len(analyses) != 3 {
+		t.Fatalf("Expected three analyses, got %v", analyses)
+	}
+
+	scoped := analyses[0]
+	if len(scoped.Ranges) != 1 || scoped.Ranges[0] != "^4.17.20" {
+		t.Errorf("Expected only the dependency of @scope/left to be affected, got %v", scoped.Ranges)
+	}
+
+	if len(scoped.Advisories) != 1 || scoped.Newer != "4.17.21" || scoped.Natural["^4.17.20"] != "4.17.21" || !scoped.Prunable {
+		t.Errorf("Expected a vulnerable, outdated and unneeded resolution, got %+v", scoped)
+	}
+
+	for _, unused := range analyses[1:] {
+		if len(unused.Ranges) != 0 || !unused.Prunable {
+			t.Errorf("Expected %s to be unused, got %+v", unused.Key, unused)
+		}
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	project := yarn.Package{
+		Dependencies: map[string]string{"lodash": "^4.17.21"},
+		Resolutions:  map[string]string{"**/left": "1.0.0"},
+		NpmOverrides: []byte(`{"@scope/left": {".": "1.2.0", "lodash": "$lodash"}, "foo@^2": "2.1.0"}`),
+		Pnpm:         &yarn.PnpmSettings{Overrides: map[string]string{"@scope/left@1>lodash@<4.17.21": "4.17.21"}},
+	}
+
+	overrides, err := project.Overrides()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"overrides @scope/left > . [] @scope/left  1.2.0",
+		"overrides @scope/left > lodash [@scope/left] lodash  ^4.17.21",
+		"overrides foo@^2 [] foo ^2 2.1.0",
+		"pnpm.overrides @scope/left@1>lodash@<4.17.21 [@scope/left@1] lodash <4.17.21 4.17.21",
+		"resolutions **/left [**] left  1.0.0",
+	}
+
+	if len(overrides) != len(expected) {
+		t.Fatalf("Expected %d overrides, got %v", len(expected), overrides)
+	}
+
+	for i, override := range overrides {
+		if actual := fmt.Sprintf("%s %s %v %s %s %s", override.Source, override.Key, override.Parents, override.Name, override.Range, override.Value); actual != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], actual)
+		}
+	}
+}
+
+func TestAnalyzePnpmOverride(t *testing.T) {
+	lock, err := yarn.ReadLock(writeTestLock(t, testLock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overrides, err := (&yarn.Package{Pnpm: &yarn.PnpmSettings{Overrides: map[string]string{"lodash@<4.17.21": "4.17.21"}}}).Overrides()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analyses := lock.AnalyzeResolutions(overrides, nil)
+	if len(analyses) != 1 || len(analyses[0].Ranges) != 2 {
+		t.Errorf("Expected the override to apply to the versions matching its range, got %+v", analyses)
+	}
+}
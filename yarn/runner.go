@@ -0,0 +1,103 @@
+package yarn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"gnarl/semver"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+type Runner interface {
+	Install(ctx context.Context) error
+	Dedupe(ctx context.Context) error
+	Version(ctx context.Context) (*semver.Version, error)
+	Audit(ctx context.Context) ([]byte, error)
+}
+
+type ExecRunner struct {
+	Binary  string
+	Args    []string
+	Cwd     string
+	Timeout time.Duration
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+func NewExecRunner(cwd string) *ExecRunner {
+	return &ExecRunner{Binary: "yarn", Cwd: cwd}
+}
+
+func (runner *ExecRunner) Install(ctx context.Context) error {
+	_, err := runner.run(ctx, "install")
+	return err
+}
+
+func (runner *ExecRunner) Dedupe(ctx context.Context) error {
+	_, err := runner.run(ctx, "dedupe")
+	return err
+}
+
+func (runner *ExecRunner) Version(ctx context.Context) (*semver.Version, error) {
+	out, err := runner.run(ctx, "--version")
+	if err != nil {
+		return nil, err
+	}
+
+	return semver.ParseVersion(strings.TrimSpace(string(out)))
+}
+
+// Audit returns the output of `yarn npm audit` even when it fails, since yarn 4 exits non-zero on findings.
+func (runner *ExecRunner) Audit(ctx context.Context) ([]byte, error) {
+	return runner.run(ctx, "npm", "audit", "--json", "--recursive")
+}
+
+func (runner *ExecRunner) run(ctx context.Context, args ...string) ([]byte, error) {
+	if runner.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, runner.Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, runner.Binary, append(append([]string{}, runner.Args...), args...)...)
+	cmd.Dir = runner.Cwd
+	cmd.Stdout = tee(&stdout, runner.Stdout)
+	cmd.Stderr = tee(&stderr, runner.Stderr)
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return stdout.Bytes(), fmt.Errorf("%s %s: %v", runner.Binary, strings.Join(args, " "), ctx.Err())
+	}
+
+	if err != nil {
+		output := stderr.String()
+		if strings.TrimSpace(output) == "" {
+			output = stdout.String()
+		}
+
+		return stdout.Bytes(), fmt.Errorf("%s %s: %v\n%s", runner.Binary, strings.Join(args, " "), err, lastLines(output, 20))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+func tee(buffer *bytes.Buffer, writer io.Writer) io.Writer {
+	if writer == nil {
+		return buffer
+	}
+
+	return io.MultiWriter(buffer, writer)
+}
+
+func lastLines(output string, count int) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) > count {
+		lines = lines[len(lines)-count:]
+	}
+
+	return strings.Join(lines, "\n")
+}
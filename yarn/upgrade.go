@@ -0,0 +1,209 @@
+package yarn
+
+import (
+	"encoding/json"
+	"fmt"
+	"gnarl/semver"
+	"log"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Upgrade moves the lock entries matching the pattern to the newest published version satisfying each descriptor.
+// Checksums of new versions are left for yarn to fill in.
+func (lock *Lock) Upgrade(pattern *ResetPattern, metadata Metadata) error {
+	byPackage := make(map[string][]string)
+	for key, resolution := range lock.resolutions {
+		name, reference := splitLocator(resolution.Resolution)
+		if strings.HasPrefix(reference, "npm:") && pattern.matches(key, resolution) {
+			byPackage[name] = append(byPackage[name], key)
+		}
+	}
+
+	var names []string
+	for name := range byPackage {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		packument, err := metadata.Packument(name)
+		if err != nil {
+			return err
+		}
+
+		lock.upgrade(name, byPackage[name], packument)
+	}
+
+	return nil
+}
+
+func (lock *Lock) upgrade(name string, keys []string, packument *Packument) {
+	npmPrefix := lock.usesNpmProtocol()
+
+	existing := make(map[string]Resolution)
+	for _, resolution := range lock.resolutions {
+		if candidate, _ := splitLocator(resolution.Resolution); candidate == name {
+			existing[resolution.Version] = resolution
+		}
+	}
+
+	entries := make(map[string]Resolution)
+	changed := false
+	for _, key := range keys {
+		current := lock.resolutions[key]
+		for _, descriptor := range strings.Split(key, ", ") {
+			_, rng := splitLocator(descriptor)
+			newest := newestSatisfying(packument, strings.TrimPrefix(rng, "npm:"), current.Version)
+			if newest == "" || newest == current.Version {
+				entries[descriptor] = current
+				continue
+			}
+
+			resolution, ok := existing[newest]
+			if !ok {
+				resolution = newResolution(name, packument.Versions[newest], npmPrefix)
+				if lock.Format().Manager() != Berry {
+					resolution.Resolved, resolution.Integrity = packument.Versions[newest].Dist.classic()
+				}
+
+				existing[newest] = resolution
+			}
+
+			log.Printf("Upgrade %s %s -> %s", descriptor, current.Version, newest)
+			entries[descriptor] = resolution
+			changed = true
+		}
+	}
+
+	if !changed {
+		return
+	}
+
+	for _, key := range keys {
+		delete(lock.resolutions, key)
+	}
+
+	for key, resolution := range lock.resolutions {
+		if candidate, _ := splitLocator(resolution.Resolution); candidate == name {
+			for _, descriptor := range strings.Split(key, ", ") {
+				entries[descriptor] = resolution
+			}
+
+			delete(lock.resolutions, key)
+		}
+	}
+
+	lock.group(entries)
+	lock.dirty = true
+}
+
+func newestSatisfying(packument *Packument, rng string, current string) string {
+	request, err := semver.ParseRequest(rng)
+	if err != nil {
+		return ""
+	}
+
+	var newest *semver.Version
+	for published := range packument.Versions {
+		version, err := semver.ParseVersion(published)
+		if err != nil || !request.Matches(version) || version.Pre != "" && !request.NamesPrerelease() {
+			continue
+		}
+
+		if newest == nil || version.Compare(newest) > 0 {
+			newest = version
+		}
+	}
+
+	if newest == nil {
+		return ""
+	}
+
+	if version, err := semver.ParseVersion(current); err == nil && version.Compare(newest) >= 0 {
+		return current
+	}
+
+	return newest.String()
+}
+
+func newResolution(name string, manifest Manifest, npmPrefix bool) Resolution {
+	resolution := Resolution{
+		Version:      manifest.Version,
+		Resolution:   fmt.Sprintf("%s@npm:%s", name, manifest.Version),
+		Bin:          manifest.bin(name),
+		LanguageName: "node",
+		LinkType:     "hard",
+	}
+
+	prefix := func(request string) string {
+		if npmPrefix && !strings.Contains(request, ":") {
+			return "npm:" + request
+		}
+
+		return request
+	}
+
+	dependencies := make(map[string]string)
+	dependenciesMeta := make(map[string]DependencyMeta)
+	for dependency, request := range manifest.Dependencies {
+		dependencies[dependency] = prefix(request)
+	}
+
+	for dependency, request := range manifest.OptionalDependencies {
+		dependencies[dependency] = prefix(request)
+		dependenciesMeta[dependency] = DependencyMeta{Optional: true}
+	}
+
+	if len(dependencies) > 0 {
+		resolution.Dependencies = dependencies
+	}
+
+	if len(dependenciesMeta) > 0 {
+		resolution.DependenciesMeta = dependenciesMeta
+	}
+
+	if len(manifest.PeerDependencies) > 0 {
+		resolution.PeerDependencies = manifest.PeerDependencies
+	}
+
+	return resolution
+}
+
+func (manifest Manifest) bin(name string) map[string]string {
+	if len(manifest.Bin) == 0 {
+		return nil
+	}
+
+	var single string
+	if json.Unmarshal(manifest.Bin, &single) == nil {
+		return map[string]string{path.Base(name): single}
+	}
+
+	var bins map[string]string
+	if json.Unmarshal(manifest.Bin, &bins) == nil && len(bins) > 0 {
+		return bins
+	}
+
+	return nil
+}
+
+// usesNpmProtocol tells whether most plain dependency ranges are written with the npm: protocol, as yarn 4 does.
+// Ranges of other protocols, like workspace: and patch:, do not count.
+func (lock *Lock) usesNpmProtocol() bool {
+	var npm, plain int
+	for _, resolution := range lock.resolutions {
+		for _, request := range resolution.Dependencies {
+			switch {
+			case strings.HasPrefix(request, "npm:"):
+				npm++
+			case !strings.Contains(request, ":"):
+				plain++
+			}
+		}
+	}
+
+	return npm > plain
+}
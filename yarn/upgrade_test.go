@@ -0,0 +1,66 @@
+package yarn_test
+
+import (
+	"gnarl/yarn"
+	"strings"
+	"testing"
+)
+
+func TestUpgrade(t *testing.T) {
+	lock := mustParseLock(t, testLock)
+	metadata := fakeMetadata{
+		"lodash": {Versions: map[string]yarn.Manifest{
+			"4.17.20": {Version: "4.17.20"},
+			"4.17.21": {Version: "4.17.21", Dependencies: map[string]string{"tslib": "^2.0.0"}},
+			"5.0.0":   {Version: "5.0.0"},
+		}},
+	}
+
+	pattern, err := yarn.ParseResetPattern("lodash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lock.Upgrade(pattern, metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := lockedLocators(lock); actual != "@scope/left@1.2.0 lodash@4.17.21" {
+		t.Errorf("Expected lodash to be upgraded within ^4, got %s", actual)
+	}
+
+	diff := yarn.DiffLocks(mustParseLock(t, testLock), lock)
+	if len(diff.Changed) != 1 || len(diff.AddedDescriptors) != 0 || len(diff.RemovedDescriptors) != 0 {
+		t.Errorf("Expected only a version change, got %v", diff)
+	}
+}
+
+func TestUpgradeSkipsPrereleases(t *testing.T) {
+	metadata := fakeMetadata{
+		"lodash": {Versions: map[string]yarn.Manifest{
+			"4.17.20":      {Version: "4.17.20"},
+			"4.17.21":      {Version: "4.17.21"},
+			"5.0.0-beta.1": {Version: "5.0.0-beta.1"},
+		}},
+	}
+
+	pattern, err := yarn.ParseResetPattern("lodash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for descriptor, expected := range map[string]string{
+		"lodash@npm:*":                "lodash@4.17.21",
+		"lodash@npm:^5.0.0-beta.1":    "lodash@5.0.0-beta.1",
+		"lodash@npm:^4.17.0 || >=4.9": "lodash@4.17.21",
+	} {
+		lock := mustParseLock(t, strings.Replace(testLock, `"lodash@npm:^4.17.0, lodash@npm:^4.17.20"`, `"`+descriptor+`"`, 1))
+		if err := lock.Upgrade(pattern, metadata); err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := lockedLocators(lock); actual != "@scope/left@1.2.0 "+expected {
+			t.Errorf("Expected %s to be upgraded to %s, got %s", descriptor, expected, actual)
+		}
+	}
+}